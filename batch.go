@@ -0,0 +1,109 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxPlaceholders is the limit of bind arguments in a statement of both MySQL and PostgreSQL.
+const maxPlaceholders = 65535
+
+// BatchResult is the result of statements run in batches, e.g. by Creates.
+type BatchResult struct {
+	// Rows is the total of rows affected.
+	Rows int64
+
+	// InsertIDs are the first generated id of each batch, 0 if the driver does not report it(PostgreSQL).
+	InsertIDs []int64
+}
+
+// LastInsertId returns the first generated id of the first batch,
+// the same as LastInsertId of a multi-row INSERT in MySQL.
+func (r *BatchResult) LastInsertId() (int64, error) {
+	if len(r.InsertIDs) == 0 {
+		return 0, nil
+	}
+	return r.InsertIDs[0], nil
+}
+
+func (r *BatchResult) RowsAffected() (int64, error) { return r.Rows, nil }
+
+// add adds result of a batch.
+func (r *BatchResult) add(result sql.Result) {
+	if n, err := result.RowsAffected(); err == nil {
+		r.Rows += n
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		id = 0
+	}
+	r.InsertIDs = append(r.InsertIDs, id)
+}
+
+// batchSize returns rows per statement of columns, limited by `BatchSize` and maxPlaceholders.
+func (its *DBWrapper) batchSize(columns int) int {
+	if columns < 1 {
+		columns = 1
+	}
+	size := maxPlaceholders / columns
+	if its.BatchSize > 0 && its.BatchSize < size {
+		size = its.BatchSize
+	}
+	return size
+}
+
+// inBatches runs fn on items [lo, hi) of total items in batches of size,
+// in one transaction if `BatchTx` is set and there is more than one batch.
+func (its *DBWrapper) inBatches(ctx context.Context, db Executor, total, size int, fn func(ctx context.Context, db Executor, lo, hi int) error) error {
+	run := func(db Executor) error {
+		for lo := 0; lo < total; lo += size {
+			hi := lo + size
+			if hi > total {
+				hi = total
+			}
+			if err := fn(ctx, db, lo, hi); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !its.BatchTx || total <= size {
+		return run(db)
+	}
+	return its.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		return run(tx)
+	})
+}
+
+// insertRowsStmt renders INSERT of rows, values of keys in each row.
+func (its *DBWrapper) insertRowsStmt(st *stmt, keys []string, rows []map[string]interface{}) (string, error) {
+	quotedKeys, err := st.columns(keys)
+	if err != nil {
+		return "", err
+	}
+
+	recordsPlaceholder := make([]string, 0, len(rows))
+	for _, itemMap := range rows {
+		placeholders := make([]string, 0, len(keys))
+		for _, k := range keys {
+			switch v := itemMap[k].(type) {
+			case map[string]interface{}:
+				placeholders = append(placeholders, st.bind(k, JSONB(v)))
+			default:
+				placeholders = append(placeholders, st.bind(k, v))
+			}
+		}
+		recordsPlaceholder = append(recordsPlaceholder, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		st.table,
+		strings.Join(quotedKeys, ","),
+		strings.Join(recordsPlaceholder, ","),
+	), nil
+}
@@ -0,0 +1,94 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBatchSize(t *testing.T) {
+	mgr := &DBWrapper{}
+	if n := mgr.batchSize(5); n != 13107 {
+		t.Errorf("expected batchSize() is limited by placeholders, got %d", n)
+	}
+	mgr.BatchSize = 100
+	if n := mgr.batchSize(5); n != 100 {
+		t.Errorf("expected batchSize() is limited by BatchSize, got %d", n)
+	}
+	mgr.BatchSize = 100000
+	if n := mgr.batchSize(2); n != 32767 {
+		t.Errorf("expected batchSize() is limited by placeholders over BatchSize, got %d", n)
+	}
+}
+
+func TestInBatches(t *testing.T) {
+	mgr := &DBWrapper{}
+	ranges := []string{}
+	err := mgr.inBatches(context.Background(), nil, 5, 2, func(ctx context.Context, db Executor, lo, hi int) error {
+		ranges = append(ranges, fmt.Sprintf("%d-%d", lo, hi))
+		return nil
+	})
+	if s := fmt.Sprint(ranges); err != nil || s != "[0-2 2-4 4-5]" {
+		t.Errorf("expected inBatches() runs [0-2 2-4 4-5], got %s, err=%v", s, err)
+	}
+
+	boom := errors.New("boom")
+	ranges = ranges[:0]
+	err = mgr.inBatches(context.Background(), nil, 5, 2, func(ctx context.Context, db Executor, lo, hi int) error {
+		ranges = append(ranges, fmt.Sprintf("%d-%d", lo, hi))
+		return boom
+	})
+	if err != boom || len(ranges) != 1 {
+		t.Errorf("expected inBatches() stops at the first error, got %v, err=%v", ranges, err)
+	}
+
+	result := &BatchResult{}
+	result.add(insertResult(1))
+	result.add(insertResult(3))
+	if n, _ := result.RowsAffected(); n != 2 {
+		t.Errorf("expected RowsAffected() is the total, got %d", n)
+	}
+	if id, _ := result.LastInsertId(); id != 1 || fmt.Sprint(result.InsertIDs) != "[1 3]" {
+		t.Errorf("expected InsertIDs of each batch, got %v", result.InsertIDs)
+	}
+}
+
+func TestInsertRowsStmt(t *testing.T) {
+	mgr := &DBWrapper{DriverName: "postgres", TableName: "test_dbwrapper"}
+	st, _ := mgr.newStmt()
+	s, err := mgr.insertRowsStmt(st, []string{"a", "b"}, []map[string]interface{}{{"a": 1, "b": 2}, {"a": 3, "b": map[string]interface{}{}}})
+	if err != nil || s != `INSERT INTO "test_dbwrapper" ("a","b") VALUES ($1,$2),($3,$4)` {
+		t.Errorf("expected insertRowsStmt() renders rows, got %s, err=%v", s, err)
+	}
+	if _, ok := st.args[3].(JSONB); !ok {
+		t.Errorf("expected maps are bound as JSONB, got %T", st.args[3])
+	}
+}
+
+func TestCreates(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	items := []map[string]interface{}{}
+	for i := 0; i < 5; i++ {
+		items = append(items, map[string]interface{}{"mobileNo": fmt.Sprintf("1380013800%d", i)})
+	}
+
+	mgr.BatchSize = 2
+	mgr.BatchTx = true
+	result, err := mgr.Creates(db, &items)
+	if err != nil {
+		t.Fatalf("expected mgr.Creates() returns err == nil, got %v", err)
+	}
+	batch := result.(*BatchResult)
+	if batch.Rows != 5 || len(batch.InsertIDs) != 3 {
+		t.Errorf("expected mgr.Creates() inserts 5 records in 3 batches, got %+v", batch)
+	}
+
+	tearDown(mgr)
+}
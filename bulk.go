@@ -0,0 +1,349 @@
+package dbwrapper
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// RowSource yields rows of BulkLoad one by one, values in order of columns.
+// It returns io.EOF after the last row.
+type RowSource func() ([]interface{}, error)
+
+// SliceRows returns a RowSource of rows.
+func SliceRows(rows [][]interface{}) RowSource {
+	i := 0
+	return func() ([]interface{}, error) {
+		if i >= len(rows) {
+			return nil, io.EOF
+		}
+		i++
+		return rows[i-1], nil
+	}
+}
+
+// BulkLoadOptions tunes BulkLoad.
+type BulkLoadOptions struct {
+	// Progress is called with the count of rows loaded so far, every ProgressEvery rows and after the last one.
+	// It may be called from another goroutine.
+	Progress func(rows int64)
+
+	// ProgressEvery is 10000 if it is not greater than 0.
+	ProgressEvery int
+}
+
+// readerSeq makes names of MySQL reader handlers unique.
+var readerSeq uint64
+
+// BulkLoad streams rows of columns from next into the table, it returns the count of rows loaded.
+// PostgreSQL(lib/pq) loads by COPY in a transaction, or a savepoint if db is a *sqlx.Tx.
+// MySQL loads by `LOAD DATA LOCAL INFILE`, which requires `local_infile` enabled on the server.
+// Other drivers fall back to Creates in batches, including pgx, whose COPY is not available through database/sql.
+// `Timeout` applies to each statement: the COPY or `LOAD DATA` of all rows, or each batch of the fallback.
+func (its *DBWrapper) BulkLoad(db Executor, columns []string, next RowSource, opts ...BulkLoadOptions) (rows int64, err error) {
+	return its.BulkLoadContext(context.Background(), db, columns, next, opts...)
+}
+
+// BulkLoadContext is like BulkLoad but runs with ctx.
+func (its *DBWrapper) BulkLoadContext(ctx context.Context, db Executor, columns []string, next RowSource, opts ...BulkLoadOptions) (rows int64, err error) {
+	openCtx, cancel := its.withTimeout(ctx)
+	db, err = its.executor(openCtx, db)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	if len(columns) == 0 {
+		err = errors.New("BulkLoad requires columns")
+		return
+	}
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	quotedColumns, err := st.columns(columns)
+	if err != nil {
+		return
+	}
+
+	p := newProgress(opts)
+	switch its.DriverName {
+	case "postgres":
+		rows, err = its.copyIn(ctx, db, st, columns, next, p)
+	case "mysql":
+		rows, err = its.loadData(ctx, db, st, columns, quotedColumns, next, p)
+	default:
+		rows, err = its.createsIn(ctx, db, columns, next, p)
+	}
+	// rows reported so far may be rolled back
+	if err == nil {
+		p.done()
+	}
+	return
+}
+
+// copyIn loads rows by COPY of PostgreSQL.
+func (its *DBWrapper) copyIn(ctx context.Context, db Executor, st *stmt, columns []string, next RowSource, p *progress) (rows int64, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	s := pq.CopyIn(its.TableName, columns...)
+	if i := strings.LastIndexByte(its.TableName, '.'); i >= 0 {
+		s = pq.CopyInSchema(its.TableName[:i], its.TableName[i+1:], columns...)
+	}
+
+	info := &QueryInfo{Op: "BulkLoad", SQL: s}
+	_, err = its.run(ctx, st.d, info, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		err := its.WithTx(ctx, db, func(tx *sqlx.Tx) (err error) {
+			copyStmt, err := tx.PrepareContext(ctx, s)
+			if err != nil {
+				return
+			}
+			defer copyStmt.Close()
+
+			for {
+				values, err := next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				if _, err = copyStmt.ExecContext(ctx, bulkValues(values)...); err != nil {
+					return err
+				}
+				rows++
+				p.add(1)
+			}
+			// flush buffered rows
+			_, err = copyStmt.ExecContext(ctx)
+			return
+		})
+		return &BatchResult{Rows: rows}, err
+	})
+	if err != nil {
+		rows = 0
+	}
+	return
+}
+
+// loadData loads rows by `LOAD DATA LOCAL INFILE` of MySQL, rows are encoded in the text format of it.
+func (its *DBWrapper) loadData(ctx context.Context, db Executor, st *stmt, columns, quotedColumns []string, next RowSource, p *progress) (rows int64, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	// the driver converts time.Time args to `loc` of the DSN, so does writeTSV
+	loc := time.UTC
+	if cfg, errDSN := mysql.ParseDSN(its.Dsn); errDSN == nil && cfg.Loc != nil {
+		loc = cfg.Loc
+	}
+
+	name := fmt.Sprintf("dbwrapper_%d", atomic.AddUint64(&readerSeq, 1))
+	r, w := io.Pipe()
+	started := make(chan struct{})
+	errc := make(chan error, 1)
+	mysql.RegisterReaderHandler(name, func() io.Reader {
+		close(started)
+		go func() {
+			err := writeTSV(w, len(columns), loc, next, p)
+			w.CloseWithError(err)
+			errc <- err
+		}()
+		return r
+	})
+	defer mysql.DeregisterReaderHandler(name)
+
+	s := fmt.Sprintf(`LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 `+
+		`FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`,
+		name, st.table, strings.Join(quotedColumns, ","))
+
+	result, err := its.exec(ctx, db, "BulkLoad", st, s)
+
+	// unblock the writer if the statement stopped reading, the handler was called during exec if ever
+	r.Close()
+	select {
+	case <-started:
+		if errSource := <-errc; errSource != nil && errSource != io.ErrClosedPipe {
+			return 0, errSource
+		}
+	default:
+	}
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// createsIn loads rows by Creates in batches, `Timeout` applies to each of them by Creates.
+func (its *DBWrapper) createsIn(ctx context.Context, db Executor, columns []string, next RowSource, p *progress) (rows int64, err error) {
+	size := its.batchSize(len(columns))
+	items := make([]map[string]interface{}, 0, size)
+	flush := func() error {
+		if len(items) == 0 {
+			return nil
+		}
+		result, err := its.CreatesContext(ctx, db, &items)
+		if err != nil {
+			return err
+		}
+		n, _ := result.RowsAffected()
+		rows += n
+		p.add(int64(len(items)))
+		items = items[:0]
+		return nil
+	}
+
+	for {
+		values, errNext := next()
+		if errNext == io.EOF {
+			break
+		}
+		if errNext != nil {
+			return rows, errNext
+		}
+		if len(values) != len(columns) {
+			return rows, fmt.Errorf("BulkLoad got %d values of %d columns", len(values), len(columns))
+		}
+		item := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			item[c] = values[i]
+		}
+		items = append(items, item)
+		if len(items) == size {
+			if err = flush(); err != nil {
+				return
+			}
+		}
+	}
+	err = flush()
+	return
+}
+
+// bulkValues converts maps into JSONB like Creates, values of the source are copied, not modified.
+func bulkValues(values []interface{}) []interface{} {
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = JSONB(m)
+		}
+		converted[i] = v
+	}
+	return converted
+}
+
+// writeTSV writes rows of next into w in the text format of `LOAD DATA`, time.Time is written in loc.
+func writeTSV(w io.Writer, columns int, loc *time.Location, next RowSource, p *progress) error {
+	bw := bufio.NewWriter(w)
+	for {
+		values, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(values) != columns {
+			return fmt.Errorf("BulkLoad got %d values of %d columns", len(values), columns)
+		}
+
+		for i, v := range bulkValues(values) {
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			field, err := tsvField(v, loc)
+			if err != nil {
+				return err
+			}
+			bw.WriteString(field)
+		}
+		bw.WriteByte('\n')
+		p.add(1)
+	}
+	return bw.Flush()
+}
+
+var tsvReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+// tsvField encodes v as a field of `LOAD DATA`, NULL is `\N`, time.Time is converted to loc.
+func tsvField(v interface{}, loc *time.Location) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = value
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return `\N`, nil
+	case string:
+		return tsvReplacer.Replace(v), nil
+	case []byte:
+		if v == nil {
+			return `\N`, nil
+		}
+		return tsvReplacer.Replace(string(v)), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.In(loc).Format("2006-01-02 15:04:05.999999"), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+	return tsvReplacer.Replace(fmt.Sprint(v)), nil
+}
+
+// progress reports rows loaded to BulkLoadOptions.Progress.
+type progress struct {
+	fn    func(rows int64)
+	every int64
+	rows  int64
+	last  int64
+}
+
+func newProgress(opts []BulkLoadOptions) *progress {
+	p := &progress{every: 10000}
+	for _, o := range opts {
+		if o.Progress != nil {
+			p.fn = o.Progress
+		}
+		if o.ProgressEvery > 0 {
+			p.every = int64(o.ProgressEvery)
+		}
+	}
+	return p
+}
+
+func (p *progress) add(n int64) {
+	rows := atomic.AddInt64(&p.rows, n)
+	if p.fn != nil && rows/p.every != (rows-n)/p.every {
+		atomic.StoreInt64(&p.last, rows)
+		p.fn(rows)
+	}
+}
+
+// done reports the last count of rows if it was not reported.
+func (p *progress) done() {
+	rows := atomic.LoadInt64(&p.rows)
+	if p.fn != nil && rows > 0 && atomic.LoadInt64(&p.last) != rows {
+		p.fn(rows)
+	}
+}
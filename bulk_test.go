@@ -0,0 +1,161 @@
+package dbwrapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestTSVField(t *testing.T) {
+	cases := []struct {
+		v        interface{}
+		expected string
+	}{
+		{nil, `\N`},
+		{"a\tb\nc\\d", `a\tb\nc\\d`},
+		{[]byte("x"), "x"},
+		{true, "1"},
+		{42, "42"},
+		{1.5, "1.5"},
+		{time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC), "2020-01-02 03:04:05.000006"},
+		{time.Date(2020, 1, 2, 11, 4, 5, 0, time.FixedZone("CST", 8*3600)), "2020-01-02 03:04:05"},
+		{JSONB{"a": 1}, `{"a":1}`},
+	}
+	for _, c := range cases {
+		if s, err := tsvField(c.v, time.UTC); err != nil || s != c.expected {
+			t.Errorf("expected tsvField(%v) returns %s, got %s, err=%v", c.v, c.expected, s, err)
+		}
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	reported := []int64{}
+	p := newProgress([]BulkLoadOptions{{Progress: func(rows int64) { reported = append(reported, rows) }, ProgressEvery: 2}})
+
+	var b bytes.Buffer
+	next := SliceRows([][]interface{}{{"a", 1}, {"b", nil}, {"c", 3}})
+	if err := writeTSV(&b, 2, time.UTC, next, p); err != nil {
+		t.Fatalf("expected writeTSV() returns nil, got %v", err)
+	}
+	p.done()
+	if b.String() != "a\t1\nb\t\\N\nc\t3\n" {
+		t.Errorf("expected rows in text format, got %q", b.String())
+	}
+	if fmt.Sprint(reported) != "[2 3]" {
+		t.Errorf("expected progress [2 3], got %v", reported)
+	}
+
+	err := writeTSV(&b, 3, time.UTC, SliceRows([][]interface{}{{"a", 1}}), newProgress(nil))
+	if err == nil {
+		t.Errorf("expected writeTSV() rejects rows of wrong count of values")
+	}
+	boom := errors.New("boom")
+	err = writeTSV(&b, 1, time.UTC, func() ([]interface{}, error) { return nil, boom }, newProgress(nil))
+	if err != boom {
+		t.Errorf("expected writeTSV() returns the error of the source, got %v", err)
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	rows := [][]interface{}{}
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []interface{}{fmt.Sprintf("138001%05d", i), "secret"})
+	}
+	var reported int64
+	n, err := mgr.BulkLoad(db, []string{"mobileNo", "password"}, SliceRows(rows), BulkLoadOptions{
+		Progress:      func(rows int64) { reported = rows },
+		ProgressEvery: 30,
+	})
+	if err != nil || n != 100 || reported != 100 {
+		t.Errorf("expected mgr.BulkLoad() loads 100 rows, got %d(reported %d), err=%v", n, reported, err)
+	}
+
+	tearDown(mgr)
+}
+
+func TestBulkLoadProgress(t *testing.T) {
+	RegisterDialect("bulkload_test", MySQL)
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	calls := 0
+	failAt := 0
+	mgr := &DBWrapper{DriverName: "bulkload_test", TableName: "test_dbwrapper", BatchSize: 2}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		calls++
+		if calls == failAt {
+			return ctx, errors.New("boom")
+		}
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, nil
+	}}}
+
+	reported := []int64{}
+	opts := BulkLoadOptions{Progress: func(rows int64) { reported = append(reported, rows) }, ProgressEvery: 10}
+	rows := [][]interface{}{{"13800138000"}, {"13800138001"}, {"13800138002"}}
+	if _, err := mgr.BulkLoad(db, []string{"mobileNo"}, SliceRows(rows), opts); err != nil {
+		t.Fatalf("expected BulkLoad() returns nil, got %v", err)
+	}
+	if fmt.Sprint(reported) != "[3]" {
+		t.Errorf("expected progress [3], got %v", reported)
+	}
+
+	calls, failAt, reported = 0, 2, nil
+	if _, err := mgr.BulkLoad(db, []string{"mobileNo"}, SliceRows(rows), opts); err == nil {
+		t.Errorf("expected BulkLoad() returns the error of the failed batch")
+	}
+	if len(reported) != 0 {
+		t.Errorf("expected no final progress of a failed load, got %v", reported)
+	}
+}
+
+func TestBulkValues(t *testing.T) {
+	values := []interface{}{"a", map[string]interface{}{"k": 1}}
+	converted := bulkValues(values)
+	if _, ok := converted[1].(JSONB); !ok {
+		t.Errorf("expected bulkValues() converts maps into JSONB, got %T", converted[1])
+	}
+	if _, ok := values[1].(map[string]interface{}); !ok {
+		t.Errorf("expected bulkValues() keeps values of the source, got %T", values[1])
+	}
+}
+
+func TestBulkLoadTimeout(t *testing.T) {
+	RegisterDialect("bulkload_test", MySQL)
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	// Timeout applies to each batch of the fallback, not the whole load
+	mgr := &DBWrapper{DriverName: "bulkload_test", TableName: "test_dbwrapper", BatchSize: 1, Timeout: 50 * time.Millisecond}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, ctx.Err()
+	}}}
+
+	n := 0
+	slow := func() ([]interface{}, error) {
+		if n == 4 {
+			return nil, io.EOF
+		}
+		n++
+		time.Sleep(20 * time.Millisecond)
+		return []interface{}{fmt.Sprint(n)}, nil
+	}
+	rows, err := mgr.BulkLoad(db, []string{"mobileNo"}, slow)
+	if err != nil || rows != 4 {
+		t.Errorf("expected BulkLoad() loads 4 rows in batches within Timeout, got %d, err=%v", rows, err)
+	}
+}
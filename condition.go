@@ -0,0 +1,255 @@
+package dbwrapper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is a boolean expression in WHERE, compose it with Eq, In, And, Or, etc.
+type Condition interface {
+	build(st *stmt) (string, error)
+}
+
+// comparison renders `col op value`.
+type comparison struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+// Op returns `column op value`, e.g. Op("age", ">=", 18).
+// op must be one of =, <>, !=, <, <=, >, >=, LIKE and NOT LIKE.
+func Op(column, op string, value interface{}) Condition {
+	return comparison{column, op, value}
+}
+
+// Eq returns `column = value`.
+func Eq(column string, value interface{}) Condition { return comparison{column, "=", value} }
+
+// Neq returns `column <> value`.
+func Neq(column string, value interface{}) Condition { return comparison{column, "<>", value} }
+
+// Gt returns `column > value`.
+func Gt(column string, value interface{}) Condition { return comparison{column, ">", value} }
+
+// Gte returns `column >= value`.
+func Gte(column string, value interface{}) Condition { return comparison{column, ">=", value} }
+
+// Lt returns `column < value`.
+func Lt(column string, value interface{}) Condition { return comparison{column, "<", value} }
+
+// Lte returns `column <= value`.
+func Lte(column string, value interface{}) Condition { return comparison{column, "<=", value} }
+
+// Like returns `column LIKE pattern`, wildcards in pattern are kept as is.
+func Like(column string, pattern string) Condition { return comparison{column, "LIKE", pattern} }
+
+func (c comparison) build(st *stmt) (string, error) {
+	column, err := st.column(c.column)
+	if err != nil {
+		return "", err
+	}
+	op, err := st.operator(c.op)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s", column, op, st.bind(c.column, c.value)), nil
+}
+
+type in struct {
+	column string
+	values []interface{}
+	not    bool
+}
+
+// In returns `column IN (values...)`, a single slice argument is expanded.
+// An empty list matches nothing.
+func In(column string, values ...interface{}) Condition {
+	return in{column: column, values: expand(values)}
+}
+
+// NotIn returns `column NOT IN (values...)`, a single slice argument is expanded.
+// An empty list matches everything.
+func NotIn(column string, values ...interface{}) Condition {
+	return in{column: column, values: expand(values), not: true}
+}
+
+func (c in) build(st *stmt) (string, error) {
+	column, err := st.column(c.column)
+	if err != nil {
+		return "", err
+	}
+	if len(c.values) == 0 {
+		if c.not {
+			return "1 = 1", nil
+		}
+		return "1 = 0", nil
+	}
+
+	placeholders := make([]string, 0, len(c.values))
+	for _, v := range c.values {
+		placeholders = append(placeholders, st.bind(c.column, v))
+	}
+	op := "IN"
+	if c.not {
+		op = "NOT IN"
+	}
+	return fmt.Sprintf("%s %s (%s)", column, op, strings.Join(placeholders, ",")), nil
+}
+
+// expand flattens a single slice argument, []byte is kept as one value.
+func expand(values []interface{}) []interface{} {
+	if len(values) != 1 {
+		return values
+	}
+	v := reflect.ValueOf(values[0])
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return values
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items
+}
+
+type between struct {
+	column string
+	lo, hi interface{}
+}
+
+// Between returns `column BETWEEN lo AND hi`.
+func Between(column string, lo, hi interface{}) Condition {
+	return between{column, lo, hi}
+}
+
+func (c between) build(st *stmt) (string, error) {
+	column, err := st.column(c.column)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", column, st.bind(c.column, c.lo), st.bind(c.column, c.hi)), nil
+}
+
+type isNull struct {
+	column string
+	not    bool
+}
+
+// IsNull returns `column IS NULL`.
+func IsNull(column string) Condition { return isNull{column: column} }
+
+// IsNotNull returns `column IS NOT NULL`.
+func IsNotNull(column string) Condition { return isNull{column: column, not: true} }
+
+func (c isNull) build(st *stmt) (string, error) {
+	column, err := st.column(c.column)
+	if err != nil {
+		return "", err
+	}
+	if c.not {
+		return column + " IS NOT NULL", nil
+	}
+	return column + " IS NULL", nil
+}
+
+type group struct {
+	op    string
+	conds []Condition
+}
+
+// And returns a condition matched if all conds are matched, nil conds are skipped.
+// No conds matches everything.
+func And(conds ...Condition) Condition { return group{"AND", conds} }
+
+// Or returns a condition matched if any of conds is matched, nil conds are skipped.
+// No conds matches nothing.
+func Or(conds ...Condition) Condition { return group{"OR", conds} }
+
+func (c group) build(st *stmt) (string, error) {
+	parts := []string{}
+	for _, cond := range c.conds {
+		if cond == nil {
+			continue
+		}
+		part, err := cond.build(st)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+
+	switch len(parts) {
+	case 0:
+		if c.op == "OR" {
+			return "1 = 0", nil
+		}
+		return "1 = 1", nil
+	case 1:
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " "+c.op+" ") + ")", nil
+}
+
+type not struct {
+	cond Condition
+}
+
+// Not returns a condition matched if cond is not matched.
+func Not(cond Condition) Condition { return not{cond} }
+
+func (c not) build(st *stmt) (string, error) {
+	if c.cond == nil {
+		return "", errors.New("Not() requires a condition")
+	}
+	part, err := c.cond.build(st)
+	if err != nil {
+		return "", err
+	}
+	return "NOT (" + part + ")", nil
+}
+
+// WhereMaps converts the legacy conditions `[]map[string]interface{}{{"key": k, "op": op, "value": v}}`,
+// the value "null" with op `is` or `is not` becomes IsNull or IsNotNull.
+func WhereMaps(conditionsWhere []map[string]interface{}) Condition {
+	conds := make([]Condition, 0, len(conditionsWhere))
+	for _, item := range conditionsWhere {
+		key := fmt.Sprint(item["key"])
+		op := fmt.Sprint(item["op"])
+
+		// hard-coded fix pass `is/is not null` condition
+		if v, ok := item["value"].(string); ok && v == "null" {
+			switch strings.ToUpper(strings.Join(strings.Fields(op), " ")) {
+			case "IS":
+				conds = append(conds, IsNull(key))
+				continue
+			case "IS NOT":
+				conds = append(conds, IsNotNull(key))
+				continue
+			}
+			conds = append(conds, Op(key, op, nil))
+			continue
+		}
+		conds = append(conds, Op(key, op, item["value"]))
+	}
+	return And(conds...)
+}
+
+// whereMap returns a condition matched if every column equals its value in m.
+func whereMap(m map[string]interface{}) Condition {
+	conds := make([]Condition, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		conds = append(conds, Eq(k, m[k]))
+	}
+	return And(conds...)
+}
+
+// whereClause renders cond for WHERE, a nil cond matches everything.
+func whereClause(st *stmt, cond Condition) (string, error) {
+	if cond == nil {
+		return "1 = 1", nil
+	}
+	return cond.build(st)
+}
@@ -0,0 +1,50 @@
+package dbwrapper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	cases := []struct {
+		cond Condition
+		sql  string
+		args []interface{}
+	}{
+		{Eq("id", 1), `"id" = $1`, []interface{}{1}},
+		{Neq("id", 1), `"id" <> $1`, []interface{}{1}},
+		{In("id", []int{1, 2}), `"id" IN ($1,$2)`, []interface{}{1, 2}},
+		{In("id"), "1 = 0", nil},
+		{NotIn("id", 1, 2), `"id" NOT IN ($1,$2)`, []interface{}{1, 2}},
+		{Between("age", 18, 30), `"age" BETWEEN $1 AND $2`, []interface{}{18, 30}},
+		{IsNull("password"), `"password" IS NULL`, nil},
+		{IsNotNull("password"), `"password" IS NOT NULL`, nil},
+		{Like("mobileNo", "138%"), `"mobileNo" LIKE $1`, []interface{}{"138%"}},
+		{And(), "1 = 1", nil},
+		{Or(), "1 = 0", nil},
+		{
+			Or(And(Gt("age", 18), Lte("age", 30)), Not(Eq("mobileNo", "13800138000"))),
+			`(("age" > $1 AND "age" <= $2) OR NOT ("mobileNo" = $3))`,
+			[]interface{}{18, 30, "13800138000"},
+		},
+		{
+			WhereMaps([]map[string]interface{}{
+				{"key": "password", "op": "is not", "value": "null"},
+				{"key": "id", "op": ">", "value": 1},
+			}),
+			`("password" IS NOT NULL AND "id" > $1)`,
+			[]interface{}{1},
+		},
+	}
+
+	for _, c := range cases {
+		st := &stmt{d: PostgreSQL}
+		s, err := whereClause(st, c.cond)
+		if err != nil || s != c.sql {
+			t.Errorf("expected whereClause() returns %s, got %s, err=%v", c.sql, s, err)
+		}
+		if !reflect.DeepEqual(st.args, c.args) {
+			t.Errorf("expected whereClause() binds %v, got %v", c.args, st.args)
+		}
+	}
+}
@@ -2,29 +2,33 @@
 package dbwrapper
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 
 	"github.com/jmoiron/sqlx"
 )
 
 var (
-	ErrRecordNotFound      = errors.New("record not found")
+	ErrRecordNotFound = errors.New("record not found")
+
+	// ErrDuplicatedUniqueKey is the class of *DriverError on unique key violation, test it by errors.Is.
 	ErrDuplicatedUniqueKey = errors.New("duplicated unique key")
 )
 
 type DBW interface {
-	RawQuery(db *sqlx.DB, objs interface{}, s string, args ...interface{}) error
+	RawQuery(db Executor, objs interface{}, s string, args ...interface{}) error
 }
 
 type DBWrapper struct {
@@ -32,7 +36,88 @@ type DBWrapper struct {
 	Dsn        string
 	Debug      bool
 	TableName  string
-	Columns    []string
+
+	// Logger receives SQL at debug level if `Debug` is set, slow queries at warn level and failed statements
+	// at error level, the standard logger of package log if nil. Use SlogLogger to route it to log/slog.
+	Logger Logger
+
+	// SlowQueryThreshold logs statements which take longer at warn level, regardless of `Debug`.
+	// Zero disables it.
+	SlowQueryThreshold time.Duration
+
+	// Tracer opens a span named after the method and table around every statement, NoopTracer if nil.
+	Tracer Tracer
+
+	// Hooks run around every statement in order, e.g. for tracing, auditing or rewriting SQL.
+	Hooks []Hook
+
+	// Columns is the allow-list of column names, keys out of it are rejected with ErrUnknownColumn.
+	// Empty allows any valid identifier, e.g. `w.Columns = w.GetColumns(&MyObject{})`.
+	Columns []string
+
+	// PkName is the primary key column, "id" if empty.
+	PkName string
+
+	// RedactColumns are columns whose args are replaced by "[redacted]" in logs and *QueryError,
+	// e.g. `w.RedactColumns = w.SecretColumns(&MyObject{})`.
+	RedactColumns []string
+
+	// Model is the record type of the table, e.g. `&MyObject{}`, its columns tagged `dbw:"secret"`
+	// are redacted like RedactColumns in every statement, including ones of maps.
+	Model interface{}
+
+	// RedactArgs hides all args of the statement in *QueryError.
+	RedactArgs bool
+
+	// Timeout is applied to every call whose context has no deadline, zero means no timeout.
+	Timeout time.Duration
+
+	// BatchSize is the count of records inserted by a statement of Creates at most,
+	// zero is limited by the count of placeholders only.
+	BatchSize int
+
+	// BatchTx runs batches of Creates in one transaction, or a savepoint if db is a *sqlx.Tx.
+	BatchTx bool
+
+	// SoftDeleteColumn enables soft delete if it is set, e.g. "deleted_at":
+	// Del, DeleteWhere and Purge set it to the current time instead of deleting records,
+	// and reads exclude records where it is not NULL unless WithDeleted or OnlyDeleted is passed.
+	// See also Restore and ForceDelete.
+	SoftDeleteColumn string
+
+	// CreatedColumn and UpdatedColumn are set to the current time by Create, Creates, CreateOrUpdate
+	// and their variants unless records have them, UpdatedColumn by Update and UpdateWhere regardless.
+	// The column is kept on conflict of CreateOrUpdate. Empty leaves them to the database,
+	// e.g. MySQL `DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP`.
+	CreatedColumn string
+	UpdatedColumn string
+
+	// Now returns the current time of CreatedColumn, UpdatedColumn and SoftDeleteColumn, time.Now if nil.
+	Now func() time.Time
+
+	// ConflictKeys is the unique key detected by CreateOrUpdate,
+	// required by dialects which need an explicit conflict target(PostgreSQL).
+	ConflictKeys []string
+
+	// Tuning of the shared pool used when methods are called with a nil db, see DB.
+	// Zero values keep the current settings, non-zero ones are applied when the wrapper acquires the pool.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// pools are references to shared pools held by the wrapper, released by Close.
+	poolsMu sync.Mutex
+	pools   map[string]*sqlx.DB
+
+	// secrets are secret columns of Model, see modelSecrets.
+	secretsMu    sync.Mutex
+	secretsModel reflect.Type
+	secrets      []string
+
+	// types are types of columns by table, see columnTypes.
+	typesMu sync.Mutex
+	types   map[string]map[string]string
 }
 
 // NewDBWrapper setup DSN(data source name) and table, sub-class have to override its.
@@ -45,576 +130,601 @@ func NewDBWrapper() *DBWrapper {
 }
 
 func (its *DBWrapper) OpenDB() (db *sqlx.DB, err error) {
+	return its.OpenDBContext(context.Background())
+}
+
+// OpenDBContext is like OpenDB but pings with ctx.
+func (its *DBWrapper) OpenDBContext(ctx context.Context) (db *sqlx.DB, err error) {
 	db, err = sqlx.Open(its.DriverName, its.Dsn)
 	if err != nil {
 		return
 	}
 
-	err = db.Ping()
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		db = nil
+	}
 	return
 }
 
-func (its *DBWrapper) MustOpenDB() (db *sqlx.DB) {
-	db, err := sqlx.Open(its.DriverName, its.Dsn)
-	if err != nil {
-		log.Fatalln("[fatal] sqlx.Open", its.DriverName, its.Dsn, err)
+// withTimeout applies `Timeout` to ctx which has no deadline.
+func (its *DBWrapper) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if its.Timeout <= 0 {
+		return ctx, func() {}
 	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, its.Timeout)
+}
 
-	err = db.Ping()
+// MustOpenDB is like OpenDB but panics if the database can not be opened.
+func (its *DBWrapper) MustOpenDB() (db *sqlx.DB) {
+	db, err := its.OpenDB()
 	if err != nil {
-		log.Fatalln("[fatal] db.Ping", err)
+		panic(fmt.Errorf("dbwrapper: open %s database: %w", its.DriverName, err))
 	}
 	return
 }
 
 // Get returns one record at most.
 // parameter `obj`` must be pass by `&MyObject{}`.`
-func (its *DBWrapper) Get(db *sqlx.DB, obj interface{}, columns []string, pkName string, pk interface{}) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
+// opts selects soft-deleted records by WithDeleted or OnlyDeleted, others are ignored.
+func (its *DBWrapper) Get(db Executor, obj interface{}, columns []string, pkName string, pk interface{}, opts ...ListOptions) (err error) {
+	return its.GetContext(context.Background(), db, obj, columns, pkName, pk, opts...)
+}
 
-	var columnsQuery string
-	if len(columns) > 0 {
-		columnsQuery = strings.Join(columns, ",")
-	} else {
-		columnsQuery = "*"
-	}
-	s := fmt.Sprintf("SELECT %s FROM %s WHERE %s=? LIMIT 1", columnsQuery, its.TableName, pkName)
-	var args []interface{}
-	args = append(args, pk)
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+// GetContext is like Get but runs with ctx.
+func (its *DBWrapper) GetContext(ctx context.Context, db Executor, obj interface{}, columns []string, pkName string, pk interface{}, opts ...ListOptions) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
-	err = db.Get(obj, s, args...)
-	if err == sql.ErrNoRows {
-		err = ErrRecordNotFound
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
-	return err
 
+	s, err := its.selectStmt(st, obj, columns, Eq(pkName, pk), ListOptions{Limit: 1, deleted: listOptions(1, opts).deleted})
+	if err != nil {
+		return
+	}
+	return its.getRow(ctx, db, "Get", st, obj, s)
 }
 
 // RawQuery custom SQL
-func (its *DBWrapper) RawQuery(db *sqlx.DB, objs interface{}, s string, args ...interface{}) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
+// `?` placeholders are rewritten for the dialect, e.g. `$1` of PostgreSQL, unless s has numbered ones already,
+// use numbered placeholders for statements with the `?` operator of PostgreSQL jsonb.
+func (its *DBWrapper) RawQuery(db Executor, objs interface{}, s string, args ...interface{}) (err error) {
+	return its.RawQueryContext(context.Background(), db, objs, s, args...)
+}
+
+// RawQueryContext is like RawQuery but runs with ctx.
+func (its *DBWrapper) RawQueryContext(ctx context.Context, db Executor, objs interface{}, s string, args ...interface{}) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+	d, err := its.Dialect()
+	if err != nil {
+		return
 	}
+	s = rebind(d, s)
 
-	err = db.Select(objs, s, args...)
-	return
+	return its.selectRows(ctx, db, "RawQuery", &stmt{d: d, args: args}, objs, s)
+}
 
+// RawExec custom SQL, placeholders are rewritten like RawQuery.
+func (its *DBWrapper) RawExec(db Executor, s string, args ...interface{}) (result sql.Result, err error) {
+	return its.RawExecContext(context.Background(), db, s, args...)
 }
 
-// RawExec custom SQL
-func (its *DBWrapper) RawExec(db *sqlx.DB, s string, args ...interface{}) (result sql.Result, err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
+// RawExecContext is like RawExec but runs with ctx.
+func (its *DBWrapper) RawExecContext(ctx context.Context, db Executor, s string, args ...interface{}) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	result, err = db.Exec(s, args...)
+	d, err := its.Dialect()
 	if err != nil {
-		if mysqlError, ok := err.(*mysql.MySQLError); ok {
-			if mysqlError.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-			}
-		}
+		return
 	}
-	return
+	s = rebind(d, s)
+
+	return its.exec(ctx, db, "RawExec", &stmt{d: d, args: args}, s)
 }
 
 // GetsWhere query multiple records with where conditions.
+// Legacy `[]map[string]interface{}` conditions can be converted by WhereMaps.
+// opts sets ORDER BY and OFFSET, it is accepted by Gets, Search and SearchFullText too.
 func (its *DBWrapper) GetsWhere(
-	db *sqlx.DB, objs interface{},
+	db Executor, objs interface{},
 	columns []string,
-	conditionsWhere []map[string]interface{},
-	limit int) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
+	where Condition,
+	limit int,
+	opts ...ListOptions) (err error) {
+	return its.GetsWhereContext(context.Background(), db, objs, columns, where, limit, opts...)
+}
 
-	var columnsQuery string
-	if len(columns) > 0 {
-		columnsQuery = strings.Join(columns, ",")
-	} else {
-		columnsQuery = "*"
-	}
-
-	// make WHERE always works
-	wheres := []string{
-		"1 = 1",
-	}
-	args := []interface{}{}
-	for _, item := range conditionsWhere {
-		// hard-coded fix pass `is/is not null` condition
-		v, ok := item["value"].(string)
-		if ok && v == "null" {
-			cond := fmt.Sprintf("%v %v null",
-				item["key"],
-				item["op"],
-			)
-			wheres = append(wheres, cond)
-		} else {
-			cond := fmt.Sprintf("%v %v ?",
-				item["key"],
-				item["op"],
-			)
-			wheres = append(wheres, cond)
-			args = append(args, item["value"])
-		}
+// GetsWhereContext is like GetsWhere but runs with ctx.
+func (its *DBWrapper) GetsWhereContext(
+	ctx context.Context,
+	db Executor, objs interface{},
+	columns []string,
+	where Condition,
+	limit int,
+	opts ...ListOptions) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	var s string
-	s = fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT %d",
-		columnsQuery,
-		its.TableName,
-		strings.Join(wheres, " AND "),
-		limit)
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+	s, err := its.selectStmt(st, objs, columns, where, listOptions(limit, opts))
+	if err != nil {
+		return
 	}
 
-	err = db.Select(objs, s, args...)
-	return
+	return its.selectRows(ctx, db, "GetsWhere", st, objs, s)
 }
 
 // Gets query multiple records with where conditions(operator in condition alawys equals to =).
 // DEPRECATED.
 // See also GetsWhere.
 func (its *DBWrapper) Gets(
-	db *sqlx.DB, objs interface{},
+	db Executor, objs interface{},
 	columns []string,
 	conditionsWhere *map[string]interface{},
-	limit int) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
+	limit int,
+	opts ...ListOptions) (err error) {
+	return its.GetsContext(context.Background(), db, objs, columns, conditionsWhere, limit, opts...)
+}
 
-	var columnsQuery string
-	if len(columns) > 0 {
-		columnsQuery = strings.Join(columns, ",")
-	} else {
-		columnsQuery = "*"
-	}
+// GetsContext is like Gets but runs with ctx.
+func (its *DBWrapper) GetsContext(
+	ctx context.Context,
+	db Executor, objs interface{},
+	columns []string,
+	conditionsWhere *map[string]interface{},
+	limit int,
+	opts ...ListOptions) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
-	var k string
-	wheres := []string{}
-	args := []interface{}{}
-	for k = range *conditionsWhere {
-		wheres = append(wheres, fmt.Sprintf("%s= ? ", k))
-		value := (*conditionsWhere)[k]
-		args = append(args, value)
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	var s string
-	if len(wheres) > 0 {
-		s = fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT %d",
-			columnsQuery,
-			its.TableName,
-			strings.Join(wheres, " AND "),
-			limit)
-	} else {
-		s = fmt.Sprintf("SELECT %s FROM %s LIMIT %d",
-			columnsQuery,
-			its.TableName,
-			limit)
-
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+	var where Condition
+	if conditionsWhere != nil {
+		where = whereMap(*conditionsWhere)
+	}
+	s, err := its.selectStmt(st, objs, columns, where, listOptions(limit, opts))
+	if err != nil {
+		return
 	}
 
-	err = db.Select(objs, s, args...)
-	return
+	return its.selectRows(ctx, db, "Gets", st, objs, s)
 }
 
 // Search query records with where EQUAL(=) and LIKE conditions, MySQL *ONLY*.
 func (its *DBWrapper) Search(
-	db *sqlx.DB, objs interface{},
+	db Executor, objs interface{},
 	columns []string,
 	conditionsWhere *map[string]interface{},
 	conditionsLike *map[string]interface{},
-	limit int) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
+	limit int,
+	opts ...ListOptions) (err error) {
+	return its.SearchContext(context.Background(), db, objs, columns, conditionsWhere, conditionsLike, limit, opts...)
+}
+
+// SearchContext is like Search but runs with ctx.
+func (its *DBWrapper) SearchContext(
+	ctx context.Context,
+	db Executor, objs interface{},
+	columns []string,
+	conditionsWhere *map[string]interface{},
+	conditionsLike *map[string]interface{},
+	limit int,
+	opts ...ListOptions) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	var columnsQuery string
-	if len(columns) > 0 {
-		columnsQuery = strings.Join(columns, ",")
-	} else {
-		columnsQuery = "*"
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
 
-	var k string
-	wheres := []string{}
-	// args := map[string]interface{}{}
-	args := []interface{}{}
+	conds := []Condition{}
 	if conditionsWhere != nil {
-		for k = range *conditionsWhere {
-			wheres = append(wheres, fmt.Sprintf("%s= ? ", k))
-			value := (*conditionsWhere)[k]
-			args = append(args, value)
-		}
+		conds = append(conds, whereMap(*conditionsWhere))
 	}
 
 	if conditionsLike != nil {
-		for k = range *conditionsLike {
-			wheres = append(wheres, fmt.Sprintf("%s LIKE ?", k))
-			value := fmt.Sprintf(`%%%s%%`, (*conditionsLike)[k])
-			args = append(args, value)
+		for _, k := range sortedKeys(*conditionsLike) {
+			conds = append(conds, Like(k, fmt.Sprintf(`%%%s%%`, (*conditionsLike)[k])))
 		}
 	}
 
-	s := fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT %d",
-		columnsQuery,
-		its.TableName,
-		strings.Join(wheres, " AND "),
-		limit)
-
-	if its.Debug {
-		log.Println("[debug] sql", s, args, conditionsWhere, conditionsLike)
+	s, err := its.selectStmt(st, objs, columns, And(conds...), listOptions(limit, opts))
+	if err != nil {
+		return
 	}
 
-	err = db.Select(objs, s, args...)
-	return
+	return its.selectRows(ctx, db, "Search", st, objs, s)
 }
 
 // CreateOrUpdate insert record or update record(s)
-func (its *DBWrapper) CreateOrUpdate(db *sqlx.DB, m *map[string]interface{}) (result sql.Result, err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
-
-	createKeys := []string{}
-	createValuesPlaceholder := []string{}
-	updates := []string{}
+func (its *DBWrapper) CreateOrUpdate(db Executor, m *map[string]interface{}) (result sql.Result, err error) {
+	return its.CreateOrUpdateContext(context.Background(), db, m)
+}
 
-	for k := range *m {
-		createKeys = append(createKeys, k)
-		createValuesPlaceholder = append(createValuesPlaceholder, fmt.Sprintf(":%s", k))
-		updates = append(updates, fmt.Sprintf("%s=:%s", k, k))
+// CreateOrUpdateContext is like CreateOrUpdate but runs with ctx.
+func (its *DBWrapper) CreateOrUpdateContext(ctx context.Context, db Executor, m *map[string]interface{}) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	s := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
-		its.TableName,
-		strings.Join(createKeys, ","),
-		strings.Join(createValuesPlaceholder, ","),
-		strings.Join(updates, ","),
-	)
-	if its.Debug {
-		log.Println("[debug] sql", s, m)
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
-	result, err = db.NamedExec(s, *m)
+
+	s, err := its.upsertStmt(st, its.withTimestamps(*m, its.now(), true), its.ConflictKeys)
 	if err != nil {
-		if mysqlError, ok := err.(*mysql.MySQLError); ok {
-			if mysqlError.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-			}
-		}
+		return
 	}
+	result, err = its.exec(ctx, db, "CreateOrUpdate", st, s)
 
 	return
 }
 
 // Update update a record
 func (its *DBWrapper) Update(
-	db *sqlx.DB,
+	db Executor,
 	pkName string,
 	changes map[string]interface{},
 ) (result sql.Result, err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
+	return its.UpdateContext(context.Background(), db, pkName, changes)
+}
+
+// UpdateContext is like Update but runs with ctx.
+func (its *DBWrapper) UpdateContext(
+	ctx context.Context,
+	db Executor,
+	pkName string,
+	changes map[string]interface{},
+) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+
+	changes = its.withTimestamps(changes, its.now(), false)
 	updates := []string{}
 
-	for k := range changes {
+	for _, k := range sortedKeys(changes) {
 		if k == pkName {
 			continue
 		}
-		updates = append(updates, fmt.Sprintf("%s=:%s", k, k))
+		column, err := st.column(k)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, fmt.Sprintf("%s=%s", column, st.bind(k, changes[k])))
 
 	}
 
-	s := fmt.Sprintf("UPDATE %s SET %s WHERE %s=:%s LIMIT 1",
-		its.TableName,
-		strings.Join(updates, ","),
-		pkName,
-		pkName,
-	)
-	if its.Debug {
-		log.Println("sql", s, changes)
-	}
-	result, err = db.NamedExec(s, changes)
+	w, err := whereClause(st, Eq(pkName, changes[pkName]))
 	if err != nil {
-		if mysqlError, ok := err.(*mysql.MySQLError); ok {
-			if mysqlError.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-			}
-		}
+		return
 	}
+
+	s := clause(
+		fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+			st.table,
+			strings.Join(updates, ","),
+			w,
+		),
+		st.d.UpdateLimit(1),
+	)
+	result, err = its.exec(ctx, db, "Update", st, s)
 	return
 }
 
 // Create insert one record
-func (its *DBWrapper) Create(db *sqlx.DB, m *map[string]interface{}) (result sql.Result, err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
-
-	createKeys := []string{}
-	createValuesPlaceholder := []string{}
-	updates := []string{}
+func (its *DBWrapper) Create(db Executor, m *map[string]interface{}) (result sql.Result, err error) {
+	return its.CreateContext(context.Background(), db, m)
+}
 
-	for k := range *m {
-		createKeys = append(createKeys, k)
-		createValuesPlaceholder = append(createValuesPlaceholder, fmt.Sprintf(":%s", k))
-		updates = append(updates, fmt.Sprintf("%s=:%s", k, k))
+// CreateContext is like Create but runs with ctx.
+func (its *DBWrapper) CreateContext(ctx context.Context, db Executor, m *map[string]interface{}) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	s := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		its.TableName,
-		strings.Join(createKeys, ","),
-		strings.Join(createValuesPlaceholder, ","),
-	)
-	if its.Debug {
-		log.Println("[debug] sql", s, m)
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
-	result, err = db.NamedExec(s, *m)
+
+	s, err := its.insertStmt(st, its.withTimestamps(*m, its.now(), true))
 	if err != nil {
-		if errMysql, ok := err.(*mysql.MySQLError); ok {
-			// duplicated record
-			if errMysql.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-				return
-			}
-		}
+		return
 	}
+	result, err = its.exec(ctx, db, "Create", st, s)
 
 	return
 }
 
-// Creates insert records in bulk
-func (its *DBWrapper) Creates(db *sqlx.DB, items *[]map[string]interface{}) (result sql.Result, err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
-	}
+// Creates insert records in bulk.
+// Records are split into batches of `BatchSize` at most, and of 65535 placeholders at most,
+// the result is a *BatchResult. If a batch fails, the result covers batches before it,
+// which are rolled back if `BatchTx` is set.
+func (its *DBWrapper) Creates(db Executor, items *[]map[string]interface{}) (result sql.Result, err error) {
+	return its.CreatesContext(context.Background(), db, items)
+}
 
-	createKeys := []string{}
-	recordsPlaceholder := []string{}
-	args := []interface{}{}
+// CreatesContext is like Creates but runs with ctx.
+func (its *DBWrapper) CreatesContext(ctx context.Context, db Executor, items *[]map[string]interface{}) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
-	itemMap := (*items)[0]
-	for k := range itemMap {
-		createKeys = append(createKeys, k)
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
-	totalKeys := len(itemMap)
 
-	i := 1
-	for _, itemMap := range *items {
-		if len(itemMap) != totalKeys {
+	if len(*items) == 0 {
+		err = errors.New("Creates requires records")
+		return
+	}
+	rows := its.withCreatedRows(*items)
+	createKeys := sortedKeys(rows[0])
+	for _, itemMap := range rows {
+		if len(itemMap) != len(createKeys) {
 			err = errors.New("count of keys must be equal in bulk insert")
 			return
 		}
-		placeholders := []string{}
-
-		for _, k := range createKeys {
-			v := itemMap[k]
-
-			switch v.(type) {
-			case map[string]interface{}:
-				{
-
-					var j JSONB
-					for key, value := range v.(map[string]interface{}) {
-						j[key] = value
-					}
-
-					args = append(args, j)
-				}
-			default:
-				{
-					args = append(args, v)
-				}
-			}
-
-			if its.DriverName == "mysql" {
-				placeholders = append(placeholders, "?")
-			} else if its.DriverName == "postgres" {
-				placeholders = append(placeholders, fmt.Sprintf("$%d", i))
-			} else {
-				err = errors.New("got unsupport driver " + its.DriverName)
-				return
-			}
-
-			i++
-		}
-
-		l := fmt.Sprintf("(%s)", strings.Join(placeholders, ","))
-		recordsPlaceholder = append(recordsPlaceholder, l)
 	}
 
-	s := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
-		its.TableName,
-		strings.Join(createKeys, ","),
-		strings.Join(recordsPlaceholder, ","),
-	)
+	batch := &BatchResult{}
+	err = its.inBatches(ctx, db, len(rows), its.batchSize(len(createKeys)), func(ctx context.Context, db Executor, lo, hi int) error {
+		st, err := its.newStmt()
+		if err != nil {
+			return err
+		}
+		s, err := its.insertRowsStmt(st, createKeys, rows[lo:hi])
+		if err != nil {
+			return err
+		}
+		r, err := its.exec(ctx, db, "Creates", st, s)
+		if err != nil {
+			return err
+		}
+		batch.add(r)
+		return nil
+	})
+	return batch, err
+}
 
-	//
-	// Too many to write, just mute it.
-	//
-	//	if its.Debug {
-	//		log.Println("sql", s)
-	//		log.Println(" Args", args)
-	//	}
+// Del delete record(s), it updates `SoftDeleteColumn` instead if it is set.
+func (its *DBWrapper) Del(db Executor, pkName string, m *map[string]interface{}) (err error) {
+	return its.DelContext(context.Background(), db, pkName, m)
+}
 
-	ts := time.Now()
-	result, err = db.Exec(s, args...)
-	if its.Debug {
-		log.Println(fmt.Sprintf("[debug] Writes %d records in %v", len(*items), time.Since(ts)))
-	}
+// DelContext is like Del but runs with ctx.
+func (its *DBWrapper) DelContext(ctx context.Context, db Executor, pkName string, m *map[string]interface{}) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
 
+	db, err = its.executor(ctx, db)
 	if err != nil {
-		if errMysql, ok := err.(*mysql.MySQLError); ok {
-			// duplicated record
-			if errMysql.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-				return
-			}
-		}
+		return
 	}
 
-	return
-}
-
-// Del delete record(s)
-func (its *DBWrapper) Del(db *sqlx.DB, pkName string, m *map[string]interface{}) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
+	st, err := its.newStmt()
+	if err != nil {
+		return
 	}
 
-	conditions := []string{}
-
-	for k := range *m {
-		conditions = append(conditions, fmt.Sprintf("%s=:%s", k, k))
-
+	if len(*m) == 0 {
+		err = errors.New("Del requires conditions")
+		return
 	}
-
-	s := fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1", its.TableName, strings.Join(conditions, " AND "))
-	if its.Debug {
-		log.Println("[debug] sql", s, m)
+	s, err := its.deleteStmt(st, whereMap(*m), 1, false)
+	if err != nil {
+		return
 	}
-	_, err = db.NamedExec(s, *m)
+	_, err = its.exec(ctx, db, "Del", st, s)
 	return
 }
 
 // GetColumns returns query columns from tag `db` in strutt.
 func (its *DBWrapper) GetColumns(obj interface{}) []string {
-	te := reflect.TypeOf(obj).Elem()
-	columns := []string{}
-	for i := 0; i < te.NumField(); i++ {
-		field := te.Field(i).Tag.Get("db")
-		if field != "" && field != "-" {
-			columns = append(columns, field)
-		}
-	}
-	return columns
-
+	return structColumns(reflect.TypeOf(obj))
 }
 
 // SearchFullText returns query records matched fulltext index.
 // This query required created index likes `alter table mytbl add FULLTEXT ft_search (idx_col_a, idx_col_b, ...) WITH PARSER ngram`.
 // MySQL *ONLY*.
 func (its *DBWrapper) SearchFullText(
-	db *sqlx.DB, objs interface{},
+	db Executor, objs interface{},
 	columns []string,
 	columnsSearch []string,
 	q string,
-	limit int) (err error) {
-	if db == nil {
-		db, err = its.OpenDB()
+	limit int,
+	opts ...ListOptions) (err error) {
+	return its.SearchFullTextContext(context.Background(), db, objs, columns, columnsSearch, q, limit, opts...)
+}
+
+// SearchFullTextContext is like SearchFullText but runs with ctx.
+func (its *DBWrapper) SearchFullTextContext(
+	ctx context.Context,
+	db Executor, objs interface{},
+	columns []string,
+	columnsSearch []string,
+	q string,
+	limit int,
+	opts ...ListOptions) (err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+
+	s, err := its.selectStmt(st, objs, columns, fullText{columnsSearch, q}, listOptions(limit, opts))
+	if err != nil {
+		return
+	}
+
+	return its.selectRows(ctx, db, "SearchFullText", st, objs, s)
+}
+
+// fullText renders `MATCH (columns) AGAINST (q)`.
+type fullText struct {
+	columns []string
+	q       string
+}
+
+func (c fullText) build(st *stmt) (string, error) {
+	columns, err := st.columns(c.columns)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("MATCH (%s) AGAINST (%s)", strings.Join(columns, ","), st.bind("", c.q)), nil
+}
+
+// selectStmt renders SELECT of columns matched by where, no columns selects `*`.
+// dest is the destination to scan, ORDER BY columns are checked against its `db` tags.
+func (its *DBWrapper) selectStmt(st *stmt, dest interface{}, columns []string, where Condition, o ListOptions) (string, error) {
+	columnsQuery := "*"
+	if len(columns) > 0 {
+		quoted, err := st.columns(columns)
 		if err != nil {
-			return
+			return "", err
 		}
-		defer db.Close()
+		columnsQuery = strings.Join(quoted, ",")
 	}
 
-	if len(columns) == 0 {
-		columns = append(columns, "*")
+	w, err := whereClause(st, its.softDeleteScope(where, o.deleted))
+	if err != nil {
+		return "", err
 	}
 
-	args := []interface{}{}
-	args = append(args, q)
-	args = append(args, limit)
+	orderBy, err := st.orderBy(o.OrderBy, dest)
+	if err != nil {
+		return "", err
+	}
 
-	s := fmt.Sprintf("SELECT %s FROM %s WHERE MATCH (%s) AGAINST (?) LIMIT ?",
-		strings.Join(columns, ","),
-		its.TableName,
-		strings.Join(columnsSearch, ","),
-	)
+	return clause(
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s", columnsQuery, st.table, w),
+		orderBy,
+		st.d.Limit(o.Limit, o.Offset),
+	), nil
+}
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
+// insertStmt renders INSERT of one record m.
+func (its *DBWrapper) insertStmt(st *stmt, m map[string]interface{}) (string, error) {
+	keys := sortedKeys(m)
+	createKeys, err := st.columns(keys)
+	if err != nil {
+		return "", err
 	}
+	createValuesPlaceholder := []string{}
 
-	err = db.Select(objs, s, args...)
-	return
+	for _, k := range keys {
+		createValuesPlaceholder = append(createValuesPlaceholder, st.bind(k, m[k]))
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		st.table,
+		strings.Join(createKeys, ","),
+		strings.Join(createValuesPlaceholder, ","),
+	), nil
+}
+
+// upsertStmt renders INSERT of one record m which updates all columns on conflict of conflictKeys,
+// except `CreatedColumn`.
+func (its *DBWrapper) upsertStmt(st *stmt, m map[string]interface{}, conflictKeys []string) (string, error) {
+	s, err := its.insertStmt(st, m)
+	if err != nil {
+		return "", err
+	}
+
+	keys := []string{}
+	for _, k := range sortedKeys(m) {
+		if k != its.CreatedColumn {
+			keys = append(keys, k)
+		}
+	}
+	createKeys, err := st.columns(keys)
+	if err != nil {
+		return "", err
+	}
+	conflictKeys, err = st.columns(conflictKeys)
+	if err != nil {
+		return "", err
+	}
+	upsert, err := st.d.Upsert(conflictKeys, createKeys)
+	if err != nil {
+		return "", err
+	}
+	return s + " " + upsert, nil
 }
 
 // JSONB maps PostgreSQL JSONB type into `map` in Go.
@@ -655,72 +765,69 @@ func (p *JSONB) Scan(src interface{}) error {
 
 // UpdateWhere update multiple records with where conditions.
 func (its *DBWrapper) UpdateWhere(
-	db *sqlx.DB,
-	conditionsWhere []map[string]interface{},
+	db Executor,
+	where Condition,
+	updatesMap map[string]interface{},
+) (result sql.Result, err error) {
+	return its.UpdateWhereContext(context.Background(), db, where, updatesMap)
+}
+
+// UpdateWhereContext is like UpdateWhere but runs with ctx.
+func (its *DBWrapper) UpdateWhereContext(
+	ctx context.Context,
+	db Executor,
+	where Condition,
 	updatesMap map[string]interface{},
 ) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
 	limit := 10000
 
-	if db == nil {
-		db, err = its.OpenDB()
-		if err != nil {
-			return
-		}
-		defer db.Close()
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
 	}
 
-	args := []interface{}{}
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
 
+	updatesMap = its.withTimestamps(updatesMap, its.now(), false)
 	updates := []string{}
-	for key, value := range updatesMap {
-		update := fmt.Sprintf("%v=?", key)
-		args = append(args, value)
+	for _, key := range sortedKeys(updatesMap) {
+		column, err := st.column(key)
+		if err != nil {
+			return nil, err
+		}
+		update := fmt.Sprintf("%v=%s", column, st.bind(key, updatesMap[key]))
 		updates = append(updates, update)
 	}
 
-	// make WHERE always works
-	wheres := []string{
-		"1 = 1",
-	}
-
-	for _, item := range conditionsWhere {
-		// hard-coded fix pass `is/is not null` condition
-		v, ok := item["value"].(string)
-		if ok && v == "null" {
-			cond := fmt.Sprintf("%v %v null",
-				item["key"],
-				item["op"],
-			)
-			wheres = append(wheres, cond)
-		} else {
-			cond := fmt.Sprintf("%v %v ?",
-				item["key"],
-				item["op"],
-			)
-			wheres = append(wheres, cond)
-			args = append(args, item["value"])
-		}
+	w, err := whereClause(st, where)
+	if err != nil {
+		return
 	}
+	s := clause(
+		fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+			st.table,
+			strings.Join(updates, ","),
+			w),
+		st.d.UpdateLimit(limit),
+	)
 
-	var s string
-	s = fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT %d",
-		its.TableName,
-		strings.Join(updates, ","),
-		strings.Join(wheres, " AND "),
-		limit)
+	result, err = its.exec(ctx, db, "UpdateWhere", st, s)
 
-	if its.Debug {
-		log.Println("[debug] sql", s, args)
-	}
+	return
+}
 
-	result, err = db.Exec(s, args...)
-	if err != nil {
-		if mysqlError, ok := err.(*mysql.MySQLError); ok {
-			if mysqlError.Number == 1062 {
-				err = ErrDuplicatedUniqueKey
-			}
-		}
+// sortedKeys returns keys of m in order, it keeps generated SQL stable.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-
-	return
+	sort.Strings(keys)
+	return keys
 }
@@ -4,6 +4,7 @@
 package dbwrapper
 
 import (
+	"context"
 	"log"
 	"strings"
 	"testing"
@@ -54,7 +55,7 @@ func setUp(mgr *AccountProxy) {
 type Account struct {
 	ID           uint64    `json:"id" db:"id"`
 	MobileNo     string    `json:"mobileNo" db:"mobileNo"`
-	Password     string    `json:"password" db:"password"`
+	Password     string    `json:"password" db:"password" dbw:"secret"`
 	Created      time.Time `json:"created" db:"created"`
 	LastModified time.Time `json:"lastModified" db:"lastModified"`
 }
@@ -69,6 +70,7 @@ func NewAccountProxy() *AccountProxy {
 	p.Debug = true
 	p.Dsn = "test:test@tcp(127.0.0.1:3306)/test?charset=utf8mb4,utf8&timeout=2s&writeTimeout=2s&readTimeout=2s&parseTime=true"
 	p.TableName = "test_dbwrapper"
+	p.Model = &Account{}
 	return &p
 }
 
@@ -276,3 +278,22 @@ func TestSearch(t *testing.T) {
 
 	tearDown(mgr)
 }
+
+func TestWithTimeout(t *testing.T) {
+	mgr := NewAccountProxy()
+	mgr.Timeout = time.Second
+
+	ctx, cancel := mgr.withTimeout(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Errorf("expected withTimeout() sets deadline, got none")
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelParent()
+	ctx, cancel = mgr.withTimeout(parent)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) < time.Minute {
+		t.Errorf("expected withTimeout() keeps deadline of parent, got %v", deadline)
+	}
+}
@@ -0,0 +1,139 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PurgeOptions tunes Purge.
+type PurgeOptions struct {
+	// BatchSize is the count of records deleted by a statement at most, 1000 if it is not greater than 0.
+	BatchSize int
+
+	// Pause is the time to sleep between batches, which lets other transactions take locks.
+	Pause time.Duration
+}
+
+// DeleteWhere delete records matched by where conditions, it returns the count of deleted records.
+// Conditions are required, pass And() to delete all. It updates `SoftDeleteColumn` instead if it is set.
+func (its *DBWrapper) DeleteWhere(db Executor, where Condition) (rows int64, err error) {
+	return its.DeleteWhereContext(context.Background(), db, where)
+}
+
+// DeleteWhereContext is like DeleteWhere but runs with ctx.
+func (its *DBWrapper) DeleteWhereContext(ctx context.Context, db Executor, where Condition) (rows int64, err error) {
+	return its.deleteWhere(ctx, db, "DeleteWhere", where, 0, false)
+}
+
+// Purge delete records matched by where conditions in batches, with a pause between batches,
+// so a large delete does not lock the table for long. It returns the count of deleted records,
+// including the ones of batches before an error. `Timeout` applies to each batch.
+// MySQL deletes by `DELETE ... LIMIT`, PostgreSQL by `DELETE ... WHERE ctid IN (SELECT ... LIMIT)`.
+func (its *DBWrapper) Purge(db Executor, where Condition, opts ...PurgeOptions) (rows int64, err error) {
+	return its.PurgeContext(context.Background(), db, where, opts...)
+}
+
+// PurgeContext is like Purge but runs with ctx, the pause is interrupted if ctx is done.
+func (its *DBWrapper) PurgeContext(ctx context.Context, db Executor, where Condition, opts ...PurgeOptions) (rows int64, err error) {
+	o := PurgeOptions{BatchSize: 1000}
+	for _, opt := range opts {
+		if opt.BatchSize > 0 {
+			o.BatchSize = opt.BatchSize
+		}
+		if opt.Pause > 0 {
+			o.Pause = opt.Pause
+		}
+	}
+
+	for {
+		n, err := its.deleteWhere(ctx, db, "Purge", where, o.BatchSize, false)
+		rows += n
+		if err != nil || n < int64(o.BatchSize) {
+			return rows, err
+		}
+
+		if o.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return rows, ctx.Err()
+			case <-time.After(o.Pause):
+			}
+		}
+	}
+}
+
+// deleteWhere deletes records matched by where, limit records at most if it is greater than 0.
+// Records are soft deleted if `SoftDeleteColumn` is set, unless force is true.
+func (its *DBWrapper) deleteWhere(ctx context.Context, db Executor, op string, where Condition, limit int, force bool) (rows int64, err error) {
+	if where == nil {
+		err = errors.New(op + " requires conditions")
+		return
+	}
+
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	s, err := its.deleteStmt(st, where, limit, force)
+	if err != nil {
+		return
+	}
+
+	result, err := its.exec(ctx, db, op, st, s)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// deleteStmt renders DELETE of records matched by where, limit records at most if it is greater than 0.
+// If `SoftDeleteColumn` is set and force is false, it renders UPDATE of the column of not deleted records.
+// Dialects without LIMIT in DELETE delete rows selected by a subquery with LIMIT,
+// identified by ctid of PostgreSQL or the primary key of others.
+func (its *DBWrapper) deleteStmt(st *stmt, where Condition, limit int, force bool) (string, error) {
+	head := "DELETE FROM " + st.table
+	if its.SoftDeleteColumn != "" && !force {
+		column, err := st.column(its.SoftDeleteColumn)
+		if err != nil {
+			return "", err
+		}
+		// bound before the conditions, placeholders of PostgreSQL are numbered
+		head = fmt.Sprintf("UPDATE %s SET %s=%s", st.table, column, st.bind(its.SoftDeleteColumn, its.now()))
+		where = And(where, IsNull(its.SoftDeleteColumn))
+	}
+
+	w, err := whereClause(st, where)
+	if err != nil {
+		return "", err
+	}
+
+	s := fmt.Sprintf("%s WHERE %s", head, w)
+	if limit <= 0 {
+		return s, nil
+	}
+	if updateLimit := st.d.UpdateLimit(limit); updateLimit != "" {
+		return clause(s, updateLimit), nil
+	}
+
+	rowID := "ctid"
+	if st.d.Name() != PostgreSQL.Name() {
+		if rowID, err = st.column(its.pkName()); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s WHERE %s IN (%s)",
+		head,
+		rowID,
+		clause(fmt.Sprintf("SELECT %s FROM %s WHERE %s", rowID, st.table, w), st.d.Limit(limit, 0)),
+	), nil
+}
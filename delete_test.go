@@ -0,0 +1,84 @@
+package dbwrapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDeleteStmt(t *testing.T) {
+	cases := []struct {
+		driverName string
+		limit      int
+		expected   string
+	}{
+		{"mysql", 0, "DELETE FROM `test_dbwrapper` WHERE `created` < ?"},
+		{"mysql", 100, "DELETE FROM `test_dbwrapper` WHERE `created` < ? LIMIT 100"},
+		{"postgres", 1, `DELETE FROM "test_dbwrapper" WHERE ctid IN (SELECT ctid FROM "test_dbwrapper" WHERE "created" < $1 LIMIT 1)`},
+		{"postgres", 100, `DELETE FROM "test_dbwrapper" WHERE ctid IN (SELECT ctid FROM "test_dbwrapper" WHERE "created" < $1 LIMIT 100)`},
+	}
+	for _, c := range cases {
+		mgr := &DBWrapper{DriverName: c.driverName, TableName: "test_dbwrapper"}
+		st, _ := mgr.newStmt()
+		s, err := mgr.deleteStmt(st, Lt("created", "2020-01-01"), c.limit, false)
+		if err != nil || s != c.expected {
+			t.Errorf("expected deleteStmt() returns %s, got %s, err=%v", c.expected, s, err)
+		}
+	}
+
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "test_dbwrapper"}
+	if _, err := mgr.DeleteWhere(nil, nil); err == nil || err.Error() != "DeleteWhere requires conditions" {
+		t.Errorf("expected DeleteWhere() requires conditions")
+	}
+
+	// Del deletes one record at most on PostgreSQL as well
+	db, _ := sqlx.Open("postgres", "postgres://127.0.0.1:1/test")
+	defer db.Close()
+	var got string
+	mgr = &DBWrapper{DriverName: "postgres", TableName: "test_dbwrapper"}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		got = info.SQL
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, nil
+	}}}
+	if err := mgr.Del(db, "id", &map[string]interface{}{"mobileNo": "13800138000"}); err != nil {
+		t.Fatalf("expected mgr.Del() returns err == nil, got %v", err)
+	}
+	expected := `DELETE FROM "test_dbwrapper" WHERE ctid IN (SELECT ctid FROM "test_dbwrapper" WHERE "mobileNo" = $1 LIMIT 1)`
+	if got != expected {
+		t.Errorf("expected mgr.Del() renders %s, got %s", expected, got)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	items := []map[string]interface{}{}
+	for i := 0; i < 5; i++ {
+		items = append(items, map[string]interface{}{"mobileNo": fmt.Sprintf("1380013800%d", i)})
+	}
+	_, err := mgr.Creates(db, &items)
+	if err != nil {
+		t.Fatalf("expected mgr.Creates() returns err == nil, got %v", err)
+	}
+
+	n, err := mgr.DeleteWhere(db, Eq("mobileNo", "13800138000"))
+	if err != nil || n != 1 {
+		t.Errorf("expected mgr.DeleteWhere() deletes 1 record, got %d, err=%v", n, err)
+	}
+
+	n, err = mgr.Purge(db, Like("mobileNo", "138%"), PurgeOptions{BatchSize: 3, Pause: time.Millisecond})
+	if err != nil || n != 4 {
+		t.Errorf("expected mgr.Purge() deletes 4 records, got %d, err=%v", n, err)
+	}
+
+	tearDown(mgr)
+}
@@ -0,0 +1,230 @@
+package dbwrapper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dialect renders the parts of a statement which differ between database servers.
+type Dialect interface {
+	// Name returns the dialect name, e.g. "mysql".
+	Name() string
+
+	// Placeholder returns the bind variable of the n-th (1-based) argument.
+	Placeholder(n int) string
+
+	// Quote quotes an identifier such as a table or column name.
+	Quote(ident string) string
+
+	// Limit renders the LIMIT/OFFSET clause of SELECT, empty if both are zero.
+	Limit(limit, offset int) string
+
+	// UpdateLimit renders the row limit of UPDATE and DELETE, empty if unsupported.
+	UpdateLimit(limit int) string
+
+	// Upsert renders the clause appended to INSERT which updates `updates` columns on conflict.
+	// `conflict` is the unique key the conflict is detected on, some dialects require it.
+	Upsert(conflict []string, updates []string) (string, error)
+
+	// ClassifyError returns a *DriverError for known errors of the driver, e.g. unique key violation,
+	// other errors are returned as is.
+	ClassifyError(err error) error
+}
+
+var (
+	// MySQL is the dialect of github.com/go-sql-driver/mysql.
+	MySQL Dialect = mysqlDialect{}
+
+	// PostgreSQL is the dialect of github.com/lib/pq.
+	PostgreSQL Dialect = postgresDialect{}
+)
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		"mysql":    MySQL,
+		"postgres": PostgreSQL,
+		"pgx":      PostgreSQL,
+	}
+)
+
+// RegisterDialect makes a dialect available for `DriverName`, it overrides the registered one.
+func RegisterDialect(driverName string, d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[driverName] = d
+}
+
+// Dialect returns the dialect selected by `DriverName`.
+func (its *DBWrapper) Dialect() (Dialect, error) {
+	dialectsMu.RLock()
+	d, ok := dialects[its.DriverName]
+	dialectsMu.RUnlock()
+	if !ok {
+		return nil, errors.New("got unsupport driver " + its.DriverName)
+	}
+	return d, nil
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Placeholder(n int) string { return "?" }
+
+func (mysqlDialect) Quote(ident string) string {
+	return "`" + strings.Replace(ident, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Limit(limit, offset int) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		// MySQL has no OFFSET without LIMIT, use the largest row count.
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return ""
+}
+
+func (mysqlDialect) UpdateLimit(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("LIMIT %d", limit)
+}
+
+func (mysqlDialect) Upsert(conflict []string, updates []string) (string, error) {
+	if len(updates) == 0 {
+		if len(conflict) == 0 {
+			return "", errors.New("upsert requires columns to update")
+		}
+		// no-op update keeps the existing row
+		updates = conflict[:1]
+	}
+	sets := make([]string, 0, len(updates))
+	for _, k := range updates {
+		sets = append(sets, fmt.Sprintf("%s=VALUES(%s)", k, k))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ","), nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgresDialect) Quote(ident string) string {
+	return `"` + strings.Replace(ident, `"`, `""`, -1) + `"`
+}
+
+func (postgresDialect) Limit(limit, offset int) string {
+	parts := []string{}
+	if limit > 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", limit))
+	}
+	if offset > 0 {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+// UpdateLimit returns empty, PostgreSQL does not support LIMIT in UPDATE and DELETE.
+func (postgresDialect) UpdateLimit(limit int) string { return "" }
+
+func (postgresDialect) Upsert(conflict []string, updates []string) (string, error) {
+	if len(conflict) == 0 {
+		return "", errors.New("postgres upsert requires conflict columns, see DBWrapper.ConflictKeys")
+	}
+	target := fmt.Sprintf("ON CONFLICT (%s)", strings.Join(conflict, ","))
+	sets := []string{}
+	for _, k := range updates {
+		if inStrings(conflict, k) {
+			continue
+		}
+		sets = append(sets, fmt.Sprintf("%s=EXCLUDED.%s", k, k))
+	}
+	if len(sets) == 0 {
+		return target + " DO NOTHING", nil
+	}
+	return target + " DO UPDATE SET " + strings.Join(sets, ","), nil
+}
+
+// Returning renders RETURNING of column, used to populate the generated primary key.
+func (postgresDialect) Returning(column string) string { return "RETURNING " + column }
+
+// stmt accumulates positional arguments of a statement and renders their placeholders and identifiers.
+type stmt struct {
+	d          Dialect
+	args       []interface{}
+	argColumns []string // column of each arg, empty if unknown
+
+	tableName string          // as configured, used in errors
+	table     string          // quoted
+	allowed   map[string]bool // column allow-list, nil allows any
+}
+
+// bind appends v of column to the arguments and returns its placeholder, column is empty if unknown.
+func (s *stmt) bind(column string, v interface{}) string {
+	s.args = append(s.args, v)
+	s.argColumns = append(s.argColumns, column)
+	return s.d.Placeholder(len(s.args))
+}
+
+// rebind rewrites `?` placeholders in s into the dialect's, quoted strings are skipped.
+// s is returned as is if it has numbered placeholders already, e.g. `$1`,
+// so `?` operators of PostgreSQL jsonb are kept in such statements.
+func rebind(d Dialect, s string) string {
+	if d.Placeholder(1) == "?" || strings.IndexByte(s, '?') == -1 {
+		return s
+	}
+
+	var b strings.Builder
+	var quote byte
+	n := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			return s
+		case c == '?':
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// clause joins non-empty parts of a statement with spaces.
+func clause(parts ...string) string {
+	nonEmpty := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+func inStrings(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
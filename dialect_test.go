@@ -0,0 +1,70 @@
+package dbwrapper
+
+import (
+	"testing"
+)
+
+func TestDialect(t *testing.T) {
+	w := &DBWrapper{DriverName: "postgres"}
+	d, err := w.Dialect()
+	if err != nil || d != PostgreSQL {
+		t.Errorf("expected Dialect() returns PostgreSQL, got %v %v", d, err)
+	}
+
+	w.DriverName = "sqlite3"
+	if _, err = w.Dialect(); err == nil {
+		t.Errorf("expected Dialect() returns err != nil for unknown driver, got nil")
+	}
+
+	cases := []struct {
+		d           Dialect
+		placeholder string
+		quote       string
+		limit       string
+		updateLimit string
+		upsert      string
+	}{
+		{MySQL, "?", "`mobileNo`", "LIMIT 10 OFFSET 20", "LIMIT 1", "ON DUPLICATE KEY UPDATE id=VALUES(id),password=VALUES(password)"},
+		{PostgreSQL, "$3", `"mobileNo"`, "LIMIT 10 OFFSET 20", "", "ON CONFLICT (id) DO UPDATE SET password=EXCLUDED.password"},
+	}
+	for _, c := range cases {
+		if got := c.d.Placeholder(3); got != c.placeholder {
+			t.Errorf("expected %s.Placeholder(3) returns %s, got %s", c.d.Name(), c.placeholder, got)
+		}
+		if got := c.d.Quote("mobileNo"); got != c.quote {
+			t.Errorf("expected %s.Quote() returns %s, got %s", c.d.Name(), c.quote, got)
+		}
+		if got := c.d.Limit(10, 20); got != c.limit {
+			t.Errorf("expected %s.Limit() returns %s, got %s", c.d.Name(), c.limit, got)
+		}
+		if got := c.d.UpdateLimit(1); got != c.updateLimit {
+			t.Errorf("expected %s.UpdateLimit() returns %s, got %s", c.d.Name(), c.updateLimit, got)
+		}
+		got, err := c.d.Upsert([]string{"id"}, []string{"id", "password"})
+		if err != nil || got != c.upsert {
+			t.Errorf("expected %s.Upsert() returns %s, got %s %v", c.d.Name(), c.upsert, got, err)
+		}
+	}
+
+	if _, err = PostgreSQL.Upsert(nil, []string{"password"}); err == nil {
+		t.Errorf("expected PostgreSQL.Upsert() without conflict columns returns err != nil, got nil")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	s := rebind(PostgreSQL, "SELECT * FROM test WHERE a=? AND b='?' AND c=?")
+	expected := "SELECT * FROM test WHERE a=$1 AND b='?' AND c=$2"
+	if s != expected {
+		t.Errorf("expected rebind() returns %s, got %s", expected, s)
+	}
+
+	s = rebind(PostgreSQL, "SELECT * FROM test WHERE data ? 'k' AND data ?| array['a'] AND id = $1")
+	if s != "SELECT * FROM test WHERE data ? 'k' AND data ?| array['a'] AND id = $1" {
+		t.Errorf("expected rebind() keeps statements of numbered placeholders, got %s", s)
+	}
+
+	s = rebind(MySQL, "SELECT * FROM test WHERE a=?")
+	if s != "SELECT * FROM test WHERE a=?" {
+		t.Errorf("expected rebind() keeps MySQL placeholders, got %s", s)
+	}
+}
@@ -0,0 +1,228 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Classes of driver errors, test them by errors.Is.
+var (
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrDeadlock             = errors.New("deadlock")
+	ErrLockTimeout          = errors.New("lock timeout")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrConnectionLost       = errors.New("connection lost")
+)
+
+// DriverError is a driver error classified by Dialect.ClassifyError.
+// errors.Is matches both its class, e.g. ErrDuplicatedUniqueKey, and the original error.
+type DriverError struct {
+	// Class is one of ErrDuplicatedUniqueKey, ErrForeignKeyViolation, etc.
+	Class error
+
+	// Err is the original error of the driver, e.g. *mysql.MySQLError or *pq.Error.
+	Err error
+
+	// Constraint and Column are the offending ones if the driver reports them.
+	Constraint string
+	Column     string
+}
+
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Class, e.Err)
+}
+
+func (e *DriverError) Unwrap() []error { return []error{e.Class, e.Err} }
+
+// QueryError is returned by DBWrapper methods when a statement fails, e.g.
+//
+//	var queryError *dbwrapper.QueryError
+//	if errors.As(err, &queryError) {
+//		log.Println(queryError.Op, queryError.SQL, queryError.Elapsed)
+//	}
+//
+// It unwraps to the classified error, so errors.Is(err, ErrDuplicatedUniqueKey) works as well.
+// ErrRecordNotFound and errors found before the statement runs, e.g. *IdentifierError, are not wrapped.
+type QueryError struct {
+	// Op is the method, e.g. "GetsWhere".
+	Op    string
+	Table string
+	SQL   string
+
+	// Args is nil if `RedactArgs` is set.
+	Args    []interface{}
+	Elapsed time.Duration
+
+	// Err is the error classified by Dialect.ClassifyError.
+	Err error
+}
+
+// Error returns the message of the SQL fingerprint and truncated args, a bulk insert would be too long,
+// SQL and Args keep the full statement.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s %s: %v (sql: %s, args: %v, elapsed: %v)", e.Op, e.Table, e.Err, fingerprint(e.SQL), logArgs(e.Args), e.Elapsed)
+}
+
+func (e *QueryError) Unwrap() error { return e.Err }
+
+// classifyError returns d.ClassifyError(err), nil, ErrRecordNotFound and classified errors are returned as is.
+func classifyError(d Dialect, err error) error {
+	if err == nil || err == ErrRecordNotFound {
+		return err
+	}
+	var driverError *DriverError
+	var queryError *QueryError
+	if errors.As(err, &driverError) || errors.As(err, &queryError) {
+		return err
+	}
+	return d.ClassifyError(err)
+}
+
+// errorClasses names classes of errors in metrics and traces.
+var errorClasses = []struct {
+	err  error
+	name string
+}{
+	{ErrDuplicatedUniqueKey, "duplicated_unique_key"},
+	{ErrForeignKeyViolation, "foreign_key_violation"},
+	{ErrNotNullViolation, "not_null_violation"},
+	{ErrCheckViolation, "check_violation"},
+	{ErrDeadlock, "deadlock"},
+	{ErrLockTimeout, "lock_timeout"},
+	{ErrSerializationFailure, "serialization_failure"},
+	{ErrConnectionLost, "connection_lost"},
+	{context.DeadlineExceeded, "timeout"},
+	{context.Canceled, "canceled"},
+}
+
+// errorClass returns the class name of err, empty for nil and ErrRecordNotFound, "other" if it is unknown.
+func errorClass(err error) string {
+	if err == nil || err == ErrRecordNotFound {
+		return ""
+	}
+	for _, c := range errorClasses {
+		if errors.Is(err, c.err) {
+			return c.name
+		}
+	}
+	return "other"
+}
+
+// connectionLost reports whether err is a broken connection in database/sql.
+func connectionLost(err error) bool {
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+var (
+	mysqlKeyPattern        = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlColumnPattern     = regexp.MustCompile(`(?:Column|Field) '([^']+)'`)
+	mysqlForeignKeyPattern = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`")
+	mysqlCheckPattern      = regexp.MustCompile(`[Cc]heck constraint '([^']+)'`)
+)
+
+func (mysqlDialect) ClassifyError(err error) error {
+	if errors.Is(err, mysql.ErrInvalidConn) || connectionLost(err) {
+		return &DriverError{Class: ErrConnectionLost, Err: err}
+	}
+
+	var mysqlError *mysql.MySQLError
+	if !errors.As(err, &mysqlError) {
+		return err
+	}
+
+	e := &DriverError{Err: err}
+	switch mysqlError.Number {
+	case 1062, 1586:
+		e.Class = ErrDuplicatedUniqueKey
+		e.Constraint = submatch(mysqlKeyPattern, mysqlError.Message, 1)
+		// MySQL 8.0 qualifies the key by table
+		e.Constraint = e.Constraint[strings.LastIndexByte(e.Constraint, '.')+1:]
+	case 1216, 1217, 1451, 1452:
+		e.Class = ErrForeignKeyViolation
+		e.Constraint = submatch(mysqlForeignKeyPattern, mysqlError.Message, 1)
+		e.Column = submatch(mysqlForeignKeyPattern, mysqlError.Message, 2)
+	case 1048, 1364:
+		e.Class = ErrNotNullViolation
+		e.Column = submatch(mysqlColumnPattern, mysqlError.Message, 1)
+	case 3819:
+		e.Class = ErrCheckViolation
+		e.Constraint = submatch(mysqlCheckPattern, mysqlError.Message, 1)
+	case 1213:
+		e.Class = ErrDeadlock
+	case 1205:
+		e.Class = ErrLockTimeout
+	case 1053, 2006, 2013:
+		e.Class = ErrConnectionLost
+	default:
+		return err
+	}
+	return e
+}
+
+// sqlStater is implemented by errors of PostgreSQL drivers, e.g. *pq.Error and *pgconn.PgError of pgx.
+type sqlStater interface {
+	SQLState() string
+}
+
+// ClassifyError classifies errors by SQLSTATE, the constraint and column are reported for *pq.Error.
+func (postgresDialect) ClassifyError(err error) error {
+	if connectionLost(err) {
+		return &DriverError{Class: ErrConnectionLost, Err: err}
+	}
+
+	var stater sqlStater
+	if !errors.As(err, &stater) {
+		return err
+	}
+
+	e := &DriverError{Err: err}
+	var pqError *pq.Error
+	if errors.As(err, &pqError) {
+		e.Constraint, e.Column = pqError.Constraint, pqError.Column
+	}
+	code := stater.SQLState()
+	switch code {
+	case "23505":
+		e.Class = ErrDuplicatedUniqueKey
+	case "23503":
+		e.Class = ErrForeignKeyViolation
+	case "23502":
+		e.Class = ErrNotNullViolation
+	case "23514":
+		e.Class = ErrCheckViolation
+	case "40P01":
+		e.Class = ErrDeadlock
+	case "55P03":
+		e.Class = ErrLockTimeout
+	case "40001":
+		e.Class = ErrSerializationFailure
+	case "57P01", "57P02", "57P03":
+		e.Class = ErrConnectionLost
+	default:
+		// class 08 - connection exception
+		if strings.HasPrefix(code, "08") {
+			e.Class = ErrConnectionLost
+			break
+		}
+		return err
+	}
+	return e
+}
+
+func submatch(pattern *regexp.Regexp, s string, i int) string {
+	m := pattern.FindStringSubmatch(s)
+	if len(m) <= i {
+		return ""
+	}
+	return m[i]
+}
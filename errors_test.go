@@ -0,0 +1,109 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		d          Dialect
+		err        error
+		class      error
+		constraint string
+		column     string
+	}{
+		{MySQL, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '13800138000' for key 'test_dbwrapper.mobileNo'"}, ErrDuplicatedUniqueKey, "mobileNo", ""},
+		{MySQL, &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`test`.`orders`, CONSTRAINT `fk_account` FOREIGN KEY (`accountId`) REFERENCES `test_dbwrapper` (`id`))"}, ErrForeignKeyViolation, "fk_account", "accountId"},
+		{MySQL, &mysql.MySQLError{Number: 1048, Message: "Column 'mobileNo' cannot be null"}, ErrNotNullViolation, "", "mobileNo"},
+		{MySQL, &mysql.MySQLError{Number: 3819, Message: "Check constraint 'chk_age' is violated."}, ErrCheckViolation, "chk_age", ""},
+		{MySQL, &mysql.MySQLError{Number: 1213}, ErrDeadlock, "", ""},
+		{MySQL, &mysql.MySQLError{Number: 1205}, ErrLockTimeout, "", ""},
+		{MySQL, mysql.ErrInvalidConn, ErrConnectionLost, "", ""},
+		{PostgreSQL, &pq.Error{Code: "23505", Constraint: "test_dbwrapper_mobileNo_key"}, ErrDuplicatedUniqueKey, "test_dbwrapper_mobileNo_key", ""},
+		{PostgreSQL, &pq.Error{Code: "23502", Column: "mobileNo"}, ErrNotNullViolation, "", "mobileNo"},
+		{PostgreSQL, &pq.Error{Code: "40001"}, ErrSerializationFailure, "", ""},
+		{PostgreSQL, &pq.Error{Code: "08006"}, ErrConnectionLost, "", ""},
+		{PostgreSQL, fmt.Errorf("exec: %w", driver.ErrBadConn), ErrConnectionLost, "", ""},
+		{PostgreSQL, fmt.Errorf("exec: %w", sqlStateError("23505")), ErrDuplicatedUniqueKey, "", ""},
+	}
+
+	for _, c := range cases {
+		err := classifyError(c.d, c.err)
+		if !errors.Is(err, c.class) || !errors.Is(err, c.err) {
+			t.Errorf("expected classifyError(%v) is %v and wraps the original, got %v", c.err, c.class, err)
+			continue
+		}
+		driverError := &DriverError{}
+		if !errors.As(err, &driverError) || driverError.Constraint != c.constraint || driverError.Column != c.column {
+			t.Errorf("expected classifyError(%v) reports constraint %q column %q, got %+v", c.err, c.constraint, c.column, driverError)
+		}
+	}
+
+	unknown := &mysql.MySQLError{Number: 1146}
+	if err := classifyError(MySQL, unknown); err != unknown {
+		t.Errorf("expected classifyError() returns unknown errors as is, got %v", err)
+	}
+	if err := classifyError(MySQL, ErrRecordNotFound); err != ErrRecordNotFound {
+		t.Errorf("expected classifyError() returns ErrRecordNotFound as is, got %v", err)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	mgr := &DBWrapper{TableName: "test_dbwrapper"}
+	dup := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '13800138000' for key 'mobileNo'"}
+	s := "INSERT INTO `test_dbwrapper` (`mobileNo`) VALUES (?)"
+	args := []interface{}{"13800138000"}
+
+	_, err := mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: s, Args: args}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, dup
+	})
+	queryError := &QueryError{}
+	if !errors.As(err, &queryError) {
+		t.Fatalf("expected run() returns *QueryError, got %v", err)
+	}
+	if queryError.Op != "Create" || queryError.Table != "test_dbwrapper" || queryError.SQL != s || len(queryError.Args) != 1 {
+		t.Errorf("expected QueryError carries the statement, got %+v", queryError)
+	}
+	if !errors.Is(err, ErrDuplicatedUniqueKey) || !errors.Is(err, dup) {
+		t.Errorf("expected QueryError unwraps to the classified error, got %v", err)
+	}
+
+	rows := strings.Repeat("(?),", 9999) + "(?)"
+	_, err = mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Creates", SQL: "INSERT INTO `test_dbwrapper` (`mobileNo`) VALUES " + rows, Args: make([]interface{}, 10000)}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, dup
+	})
+	if errors.As(err, &queryError); len(err.Error()) > 1024 || len(queryError.Args) != 10000 {
+		t.Errorf("expected QueryError.Error() is short and Args are kept, got %d bytes, %d args", len(err.Error()), len(queryError.Args))
+	}
+
+	mgr.RedactArgs = true
+	_, err = mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: s, Args: args}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, dup
+	})
+	if errors.As(err, &queryError); queryError.Args != nil {
+		t.Errorf("expected QueryError hides args if RedactArgs is set, got %v", queryError.Args)
+	}
+
+	_, err = mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: s, Args: args}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, ErrRecordNotFound
+	})
+	if err != ErrRecordNotFound {
+		t.Errorf("expected run() returns ErrRecordNotFound as is, got %v", err)
+	}
+}
+
+// sqlStateError is an error of a PostgreSQL driver other than lib/pq, e.g. *pgconn.PgError of pgx.
+type sqlStateError string
+
+func (e sqlStateError) Error() string { return "ERROR: SQLSTATE " + string(e) }
+
+func (e sqlStateError) SQLState() string { return string(e) }
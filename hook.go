@@ -0,0 +1,61 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+)
+
+// QueryInfo describes a statement run by a DBWrapper method, hooks may change it before it runs.
+type QueryInfo struct {
+	// Op is the method, e.g. "GetsWhere".
+	Op    string
+	Table string
+
+	// SQL and Args are run after BeforeQuery hooks, which may rewrite them.
+	// Rewritten SQL must keep placeholders of the dialect.
+	SQL  string
+	Args []interface{}
+
+	columns []string // column of each arg, empty if unknown
+
+	// Dest is the destination rows are scanned into, nil for statements which return no rows.
+	Dest interface{}
+
+	// Skip short-circuits the statement if it is set by BeforeQuery, Result is returned instead.
+	// A hook may fill Dest to answer a query, e.g. from a cache.
+	Skip   bool
+	Result sql.Result
+}
+
+// Hook runs around every statement of DBWrapper methods, see DBWrapper.Hooks.
+type Hook interface {
+	// BeforeQuery is called before the statement runs, the returned context is passed to the statement
+	// and later hooks. An error aborts the statement and is returned by the method.
+	BeforeQuery(ctx context.Context, info *QueryInfo) (context.Context, error)
+
+	// AfterQuery is called after the statement finished with its result and error, also if it was skipped.
+	AfterQuery(ctx context.Context, info *QueryInfo, result sql.Result, err error)
+}
+
+// beforeQuery runs BeforeQuery of hooks in order, it stops at the first error.
+// n is the count of hooks which succeeded, only their AfterQuery is called.
+func (its *DBWrapper) beforeQuery(ctx context.Context, info *QueryInfo) (_ context.Context, n int, err error) {
+	for _, h := range its.Hooks {
+		c, err := h.BeforeQuery(ctx, info)
+		if err != nil {
+			return ctx, n, err
+		}
+		if c != nil {
+			ctx = c
+		}
+		n++
+	}
+	return ctx, n, nil
+}
+
+// afterQuery runs AfterQuery of the first n hooks in reverse order.
+func (its *DBWrapper) afterQuery(ctx context.Context, n int, info *QueryInfo, result sql.Result, err error) {
+	for i := n - 1; i >= 0; i-- {
+		its.Hooks[i].AfterQuery(ctx, info, result, err)
+	}
+}
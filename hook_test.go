@@ -0,0 +1,106 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// funcHook is a Hook of functions for tests.
+type funcHook struct {
+	before func(ctx context.Context, info *QueryInfo) (context.Context, error)
+	after  func(ctx context.Context, info *QueryInfo, result sql.Result, err error)
+}
+
+func (h funcHook) BeforeQuery(ctx context.Context, info *QueryInfo) (context.Context, error) {
+	if h.before == nil {
+		return ctx, nil
+	}
+	return h.before(ctx, info)
+}
+
+func (h funcHook) AfterQuery(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+	if h.after != nil {
+		h.after(ctx, info, result, err)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	calls := []string{}
+	var got []interface{}
+	run := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		calls = append(calls, "run")
+		got = args
+		return insertResult(1), nil
+	}
+
+	mgr := &DBWrapper{TableName: "test_dbwrapper"}
+	mgr.Hooks = []Hook{
+		funcHook{
+			before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+				calls = append(calls, "before 1")
+				info.Args[0] = "rewritten"
+				return ctx, nil
+			},
+			after: func(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+				calls = append(calls, "after 1")
+			},
+		},
+		funcHook{
+			before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+				calls = append(calls, "before 2")
+				if info.Table != "test_dbwrapper" || info.Op != "Create" {
+					t.Errorf("expected QueryInfo of the statement, got %+v", info)
+				}
+				return ctx, nil
+			},
+			after: func(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+				calls = append(calls, "after 2")
+			},
+		},
+	}
+
+	_, err := mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: "INSERT", Args: []interface{}{"origin"}}, run)
+	if err != nil {
+		t.Fatalf("expected run() returns nil, got %v", err)
+	}
+	expected := "[before 1 before 2 run after 2 after 1]"
+	if s := fmt.Sprint(calls); s != expected {
+		t.Errorf("expected hooks run in order %s, got %s", expected, s)
+	}
+	if got[0] != "rewritten" {
+		t.Errorf("expected hooks rewrite args, got %v", got)
+	}
+
+	calls = calls[:0]
+	mgr.Hooks = append(mgr.Hooks, funcHook{
+		before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+			info.Skip = true
+			info.Result = insertResult(42)
+			return ctx, nil
+		},
+	})
+	result, err := mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: "INSERT", Args: []interface{}{"origin"}}, run)
+	if id, _ := result.LastInsertId(); err != nil || id != 42 {
+		t.Errorf("expected a hook short-circuits with its result, got %v %v", result, err)
+	}
+	if inStrings(calls, "run") {
+		t.Errorf("expected a skipped statement does not run, got %v", calls)
+	}
+
+	calls = calls[:0]
+	denied := errors.New("denied")
+	mgr.Hooks[1] = funcHook{
+		before: func(ctx context.Context, info *QueryInfo) (context.Context, error) { return ctx, denied },
+	}
+	_, err = mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: "INSERT", Args: []interface{}{"origin"}}, run)
+	if !errors.Is(err, denied) {
+		t.Errorf("expected the error of BeforeQuery, got %v", err)
+	}
+	expected = "[before 1 after 1]"
+	if s := fmt.Sprint(calls); s != expected {
+		t.Errorf("expected hooks run %s on error, got %s", expected, s)
+	}
+}
@@ -0,0 +1,111 @@
+package dbwrapper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrInvalidOperator   = errors.New("invalid operator")
+	ErrUnknownColumn     = errors.New("unknown column")
+)
+
+// IdentifierError reports a table, column or operator rejected before any SQL is sent.
+// It unwraps to ErrInvalidIdentifier, ErrInvalidOperator or ErrUnknownColumn.
+type IdentifierError struct {
+	Err   error
+	Table string
+	Name  string
+}
+
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("%v %q of table %s", e.Err, e.Name, e.Table)
+}
+
+func (e *IdentifierError) Unwrap() error { return e.Err }
+
+// identPattern matches a plain or dot qualified identifier, e.g. `mobileNo` or `test.mobileNo`.
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// operators are allowed in Op and WhereMaps.
+var operators = map[string]bool{
+	"=":        true,
+	"<>":       true,
+	"!=":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"LIKE":     true,
+	"NOT LIKE": true,
+}
+
+// newStmt returns a statement builder of the wrapper's dialect, table and column allow-list.
+func (its *DBWrapper) newStmt() (*stmt, error) {
+	d, err := its.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &stmt{d: d, tableName: its.TableName}
+	if len(its.Columns) > 0 {
+		st.allowed = make(map[string]bool, len(its.Columns))
+		for _, c := range its.Columns {
+			st.allowed[c] = true
+		}
+	}
+
+	if !identPattern.MatchString(its.TableName) {
+		return nil, &IdentifierError{ErrInvalidIdentifier, its.TableName, its.TableName}
+	}
+	st.table = quoteIdent(d, its.TableName)
+	return st, nil
+}
+
+// column validates name against the identifier syntax and `Columns`, and returns it quoted.
+func (st *stmt) column(name string) (string, error) {
+	if !identPattern.MatchString(name) {
+		return "", &IdentifierError{ErrInvalidIdentifier, st.tableName, name}
+	}
+	if st.allowed != nil {
+		unqualified := name[strings.LastIndexByte(name, '.')+1:]
+		if !st.allowed[unqualified] {
+			return "", &IdentifierError{ErrUnknownColumn, st.tableName, name}
+		}
+	}
+	return quoteIdent(st.d, name), nil
+}
+
+// columns is like column for each of names.
+func (st *stmt) columns(names []string) ([]string, error) {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		q, err := st.column(name)
+		if err != nil {
+			return nil, err
+		}
+		quoted = append(quoted, q)
+	}
+	return quoted, nil
+}
+
+// operator returns op normalized if it is allowed.
+func (st *stmt) operator(op string) (string, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(op), " "))
+	if !operators[normalized] {
+		return "", &IdentifierError{ErrInvalidOperator, st.tableName, op}
+	}
+	return normalized, nil
+}
+
+// quoteIdent quotes each part of a dot qualified identifier.
+func quoteIdent(d Dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
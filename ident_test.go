@@ -0,0 +1,47 @@
+package dbwrapper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIdentifier(t *testing.T) {
+	mgr := NewAccountProxy()
+
+	st, err := mgr.newStmt()
+	if err != nil || st.table != "`test_dbwrapper`" {
+		t.Fatalf("expected newStmt() quotes table, got %v, err=%v", st, err)
+	}
+
+	for _, name := range []string{"id;DROP TABLE test", "id`", "1id", "count(*)", ""} {
+		_, err = st.column(name)
+		if !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("expected column(%q) returns ErrInvalidIdentifier, got %v", name, err)
+		}
+	}
+
+	_, err = whereClause(st, Op("id", "= 1 OR 1 =", 1))
+	if !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("expected whereClause() returns ErrInvalidOperator, got %v", err)
+	}
+
+	mgr.Columns = mgr.GetColumns(&Account{})
+	st, err = mgr.newStmt()
+	if err != nil {
+		t.Fatalf("expected newStmt() returns err == nil, got %v", err)
+	}
+	if s, err := st.column("test_dbwrapper.mobileNo"); err != nil || s != "`test_dbwrapper`.`mobileNo`" {
+		t.Errorf("expected column() returns quoted qualified column, got %s, err=%v", s, err)
+	}
+
+	_, err = st.column("salary")
+	identErr := &IdentifierError{}
+	if !errors.As(err, &identErr) || identErr.Err != ErrUnknownColumn || identErr.Name != "salary" {
+		t.Errorf("expected column() returns IdentifierError of ErrUnknownColumn, got %v", err)
+	}
+
+	mgr.TableName = "test_dbwrapper;"
+	if _, err = mgr.newStmt(); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("expected newStmt() returns ErrInvalidIdentifier, got %v", err)
+	}
+}
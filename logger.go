@@ -0,0 +1,82 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Logger receives leveled output of DBWrapper, see DBWrapper.Logger.
+// args are key-value pairs like slog, *slog.Logger satisfies it.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+}
+
+// SlogLogger returns a Logger writing to l, slog.Default() if l is nil.
+func SlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
+
+// stdLogger writes to the standard logger of package log, e.g. `[debug] sql op Get sql SELECT ...`.
+type stdLogger struct{}
+
+func (stdLogger) Log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	log.Println(append([]interface{}{"[" + strings.ToLower(level.String()) + "]", msg}, args...)...)
+}
+
+// logger returns `Logger`, the standard logger of package log if it is nil.
+func (its *DBWrapper) logger() Logger {
+	if its.Logger == nil {
+		return stdLogger{}
+	}
+	return its.Logger
+}
+
+// logDebug writes at debug level if `Debug` is set.
+func (its *DBWrapper) logDebug(ctx context.Context, msg string, args ...interface{}) {
+	if its.Debug {
+		its.logger().Log(ctx, slog.LevelDebug, msg, args...)
+	}
+}
+
+func (its *DBWrapper) logWarn(ctx context.Context, msg string, args ...interface{}) {
+	its.logger().Log(ctx, slog.LevelWarn, msg, args...)
+}
+
+// logError writes at error level if `Debug` or `Logger` is set, errors are returned to the caller anyway.
+func (its *DBWrapper) logError(ctx context.Context, msg string, args ...interface{}) {
+	if its.Debug || its.Logger != nil {
+		its.logger().Log(ctx, slog.LevelError, msg, args...)
+	}
+}
+
+// maxLogArgs is the count of args written in logs, bulk inserts have too many to write.
+const maxLogArgs = 32
+
+// logArgs returns args to write in logs, the ones after maxLogArgs are summarized by count.
+func logArgs(args []interface{}) interface{} {
+	if len(args) <= maxLogArgs {
+		return args
+	}
+	return fmt.Sprintf("%v ... (%d args)", args[:maxLogArgs], len(args))
+}
+
+// handledErrors are classes of errors callers usually handle, e.g. ErrDuplicatedUniqueKey of a create-if-absent,
+// failed statements of them are logged at debug level.
+var handledErrors = []error{ErrDuplicatedUniqueKey, ErrForeignKeyViolation, ErrNotNullViolation, ErrCheckViolation}
+
+// handledError reports whether err is one of handledErrors.
+func handledError(err error) bool {
+	for _, c := range handledErrors {
+		if errors.Is(err, c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package dbwrapper
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type entry struct {
+	level slog.Level
+	msg   string
+	args  []interface{}
+}
+
+// recordLogger records entries for tests.
+type recordLogger struct {
+	entries []entry
+}
+
+func (l *recordLogger) Log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	l.entries = append(l.entries, entry{level, msg, args})
+}
+
+func TestLogger(t *testing.T) {
+	l := &recordLogger{}
+	mgr := &DBWrapper{TableName: "test_dbwrapper", Logger: l}
+	ok := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) { return nil, nil }
+	fail := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, errors.New("boom")
+	}
+
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: "SELECT 1"}, ok)
+	if len(l.entries) != 0 {
+		t.Errorf("expected no debug output if Debug is not set, got %v", l.entries)
+	}
+
+	mgr.Debug = true
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: "SELECT 1"}, ok)
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: "SELECT 1"}, fail)
+	if len(l.entries) != 2 || l.entries[0].level != slog.LevelDebug || l.entries[1].level != slog.LevelError {
+		t.Fatalf("expected a debug and an error entry, got %v", l.entries)
+	}
+
+	l.entries = nil
+	mgr.Debug = false
+	dup := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, &mysql.MySQLError{Number: 1062}
+	}
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create", SQL: "INSERT 1"}, dup)
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: "SELECT 1"}, fail)
+	if len(l.entries) != 1 || l.entries[0].level != slog.LevelError {
+		t.Errorf("expected handled errors are logged at debug level only, got %v", l.entries)
+	}
+
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+	(&DBWrapper{}).run(context.Background(), MySQL, &QueryInfo{Op: "Get", SQL: "SELECT 1"}, fail)
+	if b.Len() != 0 {
+		t.Errorf("expected no output to the standard logger if Debug and Logger are not set, got %s", b.String())
+	}
+
+	args := make([]interface{}, maxLogArgs+1)
+	if s, ok := logArgs(args).(string); !ok || !strings.HasSuffix(s, "(33 args)") {
+		t.Errorf("expected logArgs() summarizes too many args, got %v", logArgs(args))
+	}
+}
+
+func TestMustOpenDB(t *testing.T) {
+	mgr := &DBWrapper{DriverName: "unknown"}
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !strings.Contains(err.Error(), "unknown") {
+			t.Errorf("expected MustOpenDB() panics with an error, got %v", err)
+		}
+	}()
+	mgr.MustOpenDB()
+}
@@ -0,0 +1,235 @@
+package dbwrapper
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are upper bounds in seconds of the latency histogram, the same as the Prometheus client's.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics aggregates counts, errors by class and latencies of statements per table and method,
+// and stats of pools. It is a Hook, see Instrument, and an http.Handler rendering the Prometheus text format, e.g.
+//
+//	m := dbwrapper.NewMetrics(nil)
+//	m.Instrument(w)
+//	http.Handle("/metrics", m)
+//
+// It is safe for concurrent use.
+type Metrics struct {
+	buckets []float64
+
+	mu      sync.Mutex
+	queries map[queryKey]*queryStats
+	pools   map[string]Stater
+}
+
+// Stater reports stats of a pool, *sql.DB, *sqlx.DB and *DBWrapper satisfy it.
+type Stater interface {
+	Stats() sql.DBStats
+}
+
+type queryKey struct {
+	table string
+	op    string
+}
+
+type queryStats struct {
+	count   uint64
+	errors  map[string]uint64
+	buckets []uint64 // count of each bucket, not cumulative
+	sum     float64
+}
+
+// NewMetrics returns Metrics with latency buckets in seconds, DefaultBuckets if buckets is empty.
+func NewMetrics(buckets []float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets: buckets,
+		queries: map[queryKey]*queryStats{},
+		pools:   map[string]Stater{},
+	}
+}
+
+// Instrument adds m to `Hooks` of w and exposes stats of its shared pool labeled by `TableName`.
+func (m *Metrics) Instrument(w *DBWrapper) {
+	w.Hooks = append(w.Hooks, m)
+	m.AddPool(w.TableName, w)
+}
+
+// AddPool exposes stats of pool labeled by name, it replaces the one of the same name.
+func (m *Metrics) AddPool(name string, pool Stater) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools[name] = pool
+}
+
+// metricsStartKey is the context key of the time a statement started.
+type metricsStartKey struct{}
+
+func (m *Metrics) BeforeQuery(ctx context.Context, info *QueryInfo) (context.Context, error) {
+	return context.WithValue(ctx, metricsStartKey{}, time.Now()), nil
+}
+
+func (m *Metrics) AfterQuery(ctx context.Context, info *QueryInfo, result sql.Result, err error) {
+	start, ok := ctx.Value(metricsStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	m.observe(info.Table, info.Op, time.Since(start), err)
+}
+
+// observe counts a statement of op on table which took elapsed.
+func (m *Metrics) observe(table, op string, elapsed time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := queryKey{table, op}
+	s := m.queries[key]
+	if s == nil {
+		s = &queryStats{errors: map[string]uint64{}, buckets: make([]uint64, len(m.buckets))}
+		m.queries[key] = s
+	}
+
+	s.count++
+	if class := errorClass(err); class != "" {
+		s.errors[class]++
+	}
+	seconds := elapsed.Seconds()
+	s.sum += seconds
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+			break
+		}
+	}
+}
+
+// WriteTo renders metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	m.render(&b)
+	return b.WriteTo(w)
+}
+
+// ServeHTTP renders metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+func (m *Metrics) render(b *bytes.Buffer) {
+	m.mu.Lock()
+	keys := make([]queryKey, 0, len(m.queries))
+	for k := range m.queries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].table != keys[j].table {
+			return keys[i].table < keys[j].table
+		}
+		return keys[i].op < keys[j].op
+	})
+
+	header(b, "dbwrapper_queries_total", "counter", "Count of statements by table and method.")
+	for _, k := range keys {
+		sample(b, "dbwrapper_queries_total", labels("table", k.table, "op", k.op), float64(m.queries[k].count))
+	}
+
+	header(b, "dbwrapper_query_errors_total", "counter", "Count of failed statements by table, method and error class.")
+	for _, k := range keys {
+		s := m.queries[k]
+		classes := make([]string, 0, len(s.errors))
+		for class := range s.errors {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			sample(b, "dbwrapper_query_errors_total", labels("table", k.table, "op", k.op, "class", class), float64(s.errors[class]))
+		}
+	}
+
+	header(b, "dbwrapper_query_duration_seconds", "histogram", "Latency of statements by table and method.")
+	for _, k := range keys {
+		s := m.queries[k]
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += s.buckets[i]
+			le := strconv.FormatFloat(upper, 'g', -1, 64)
+			sample(b, "dbwrapper_query_duration_seconds_bucket", labels("table", k.table, "op", k.op, "le", le), float64(cumulative))
+		}
+		sample(b, "dbwrapper_query_duration_seconds_bucket", labels("table", k.table, "op", k.op, "le", "+Inf"), float64(s.count))
+		sample(b, "dbwrapper_query_duration_seconds_sum", labels("table", k.table, "op", k.op), s.sum)
+		sample(b, "dbwrapper_query_duration_seconds_count", labels("table", k.table, "op", k.op), float64(s.count))
+	}
+
+	names := make([]string, 0, len(m.pools))
+	for name := range m.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pools := make([]Stater, len(names))
+	for i, name := range names {
+		pools[i] = m.pools[name]
+	}
+	m.mu.Unlock()
+
+	// Stats locks the pool, so it is called out of m.mu
+	stats := make([]sql.DBStats, len(pools))
+	for i, pool := range pools {
+		stats[i] = pool.Stats()
+	}
+
+	gauges := []struct {
+		name, typ, help string
+		value           func(s sql.DBStats) float64
+	}{
+		{"dbwrapper_pool_max_open_connections", "gauge", "Maximum number of open connections.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"dbwrapper_pool_open_connections", "gauge", "Number of established connections, in use and idle.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"dbwrapper_pool_in_use_connections", "gauge", "Number of connections in use.", func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"dbwrapper_pool_idle_connections", "gauge", "Number of idle connections.", func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"dbwrapper_pool_wait_count_total", "counter", "Count of waits for a connection.", func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"dbwrapper_pool_wait_duration_seconds_total", "counter", "Time blocked waiting for a connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"dbwrapper_pool_max_idle_closed_total", "counter", "Count of connections closed due to MaxIdleConns.", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"dbwrapper_pool_max_idle_time_closed_total", "counter", "Count of connections closed due to ConnMaxIdleTime.", func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+		{"dbwrapper_pool_max_lifetime_closed_total", "counter", "Count of connections closed due to ConnMaxLifetime.", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+	for _, g := range gauges {
+		header(b, g.name, g.typ, g.help)
+		for i, name := range names {
+			sample(b, g.name, labels("pool", name), g.value(stats[i]))
+		}
+	}
+}
+
+func header(b *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(b *bytes.Buffer, name, labels string, value float64) {
+	fmt.Fprintf(b, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels renders pairs of label names and values, e.g. `table="test",op="Get"`.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], labelReplacer.Replace(pairs[i+1])))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,66 @@
+package dbwrapper
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type fixedStats sql.DBStats
+
+func (s fixedStats) Stats() sql.DBStats { return sql.DBStats(s) }
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics([]float64{0.1, 1})
+	mgr := &DBWrapper{TableName: "test_dbwrapper"}
+	m.Instrument(mgr)
+	m.AddPool("reports", fixedStats{OpenConnections: 3, InUse: 1, Idle: 2, WaitDuration: 1500 * time.Millisecond})
+
+	ok := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) { return nil, nil }
+	dup := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, &mysql.MySQLError{Number: 1062}
+	}
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Get"}, ok)
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create"}, ok)
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "Create"}, dup)
+	m.observe("test_dbwrapper", "GetsWhere", 500*time.Millisecond, nil)
+
+	var b bytes.Buffer
+	m.WriteTo(&b)
+	out := b.String()
+	for _, line := range []string{
+		`dbwrapper_queries_total{table="test_dbwrapper",op="Create"} 2`,
+		`dbwrapper_queries_total{table="test_dbwrapper",op="Get"} 1`,
+		`dbwrapper_query_errors_total{table="test_dbwrapper",op="Create",class="duplicated_unique_key"} 1`,
+		`dbwrapper_query_duration_seconds_bucket{table="test_dbwrapper",op="GetsWhere",le="0.1"} 0`,
+		`dbwrapper_query_duration_seconds_bucket{table="test_dbwrapper",op="GetsWhere",le="1"} 1`,
+		`dbwrapper_query_duration_seconds_bucket{table="test_dbwrapper",op="GetsWhere",le="+Inf"} 1`,
+		`dbwrapper_query_duration_seconds_sum{table="test_dbwrapper",op="GetsWhere"} 0.5`,
+		`dbwrapper_pool_open_connections{pool="reports"} 3`,
+		`dbwrapper_pool_wait_duration_seconds_total{pool="reports"} 1.5`,
+		`dbwrapper_pool_open_connections{pool="test_dbwrapper"} 0`,
+		"# TYPE dbwrapper_query_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected metrics contain %s, got\n%s", line, out)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") || rec.Body.String() != out {
+		t.Errorf("expected ServeHTTP renders the text format, got %v", rec.Header())
+	}
+}
+
+func TestLabels(t *testing.T) {
+	if s := labels("table", `a"b\c`+"\n"); s != `table="a\"b\\c\n"` {
+		t.Errorf("expected labels() escapes values, got %s", s)
+	}
+}
@@ -0,0 +1,105 @@
+package dbwrapper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Order is a column of ORDER BY.
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Asc sorts by column in ascending order.
+func Asc(column string) Order { return Order{Column: column} }
+
+// Desc sorts by column in descending order.
+func Desc(column string) Order { return Order{Column: column, Desc: true} }
+
+// ListOptions tunes list queries like GetsWhere, e.g.
+//
+//	ListOptions{OrderBy: []Order{Desc("created"), Asc("id")}, Offset: 20}
+type ListOptions struct {
+	// OrderBy columns must be `db` tags of the destination struct.
+	OrderBy []Order
+
+	Offset int
+
+	// Limit overrides the limit argument if it is greater than 0.
+	Limit int
+
+	// deleted selects soft-deleted records, see WithDeleted and OnlyDeleted.
+	deleted deletedScope
+}
+
+// listOptions merges opts into ListOptions of limit, later options win.
+func listOptions(limit int, opts []ListOptions) ListOptions {
+	o := ListOptions{Limit: limit}
+	for _, opt := range opts {
+		o.OrderBy = append(o.OrderBy, opt.OrderBy...)
+		if opt.Offset > 0 {
+			o.Offset = opt.Offset
+		}
+		if opt.Limit > 0 {
+			o.Limit = opt.Limit
+		}
+		if opt.deleted != excludeDeleted {
+			o.deleted = opt.deleted
+		}
+	}
+	return o
+}
+
+// orderBy renders ORDER BY of orders, empty if there is none.
+// If dest is (a pointer to a slice of) struct, columns must be its `db` tags.
+func (st *stmt) orderBy(orders []Order, dest interface{}) (string, error) {
+	if len(orders) == 0 {
+		return "", nil
+	}
+
+	var tags map[string]bool
+	if dest != nil {
+		if columns := structColumns(reflect.TypeOf(dest)); len(columns) > 0 {
+			tags = make(map[string]bool, len(columns))
+			for _, c := range columns {
+				tags[c] = true
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(orders))
+	for _, o := range orders {
+		if tags != nil && !tags[o.Column] {
+			return "", &IdentifierError{ErrUnknownColumn, st.tableName, o.Column}
+		}
+		column, err := st.column(o.Column)
+		if err != nil {
+			return "", err
+		}
+		if o.Desc {
+			column += " DESC"
+		} else {
+			column += " ASC"
+		}
+		parts = append(parts, column)
+	}
+	return "ORDER BY " + strings.Join(parts, ","), nil
+}
+
+// structColumns returns `db` tags of t, pointers and slices are dereferenced.
+// It returns nil if t is not a struct.
+func structColumns(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	columns := []string{}
+	for _, f := range structFields(t) {
+		columns = append(columns, f.name)
+	}
+	return columns
+}
@@ -0,0 +1,30 @@
+package dbwrapper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListOptions(t *testing.T) {
+	mgr := NewAccountProxy()
+	st, err := mgr.newStmt()
+	if err != nil {
+		t.Fatalf("expected newStmt() returns err == nil, got %v", err)
+	}
+
+	o := listOptions(10, []ListOptions{
+		{OrderBy: []Order{Desc("created")}},
+		{OrderBy: []Order{Asc("id")}, Offset: 20},
+	})
+	accounts := []Account{}
+	s, err := mgr.selectStmt(st, &accounts, []string{"id", "mobileNo"}, Gt("id", 1), o)
+	expected := "SELECT `id`,`mobileNo` FROM `test_dbwrapper` WHERE `id` > ? ORDER BY `created` DESC,`id` ASC LIMIT 10 OFFSET 20"
+	if err != nil || s != expected {
+		t.Errorf("expected selectStmt() returns %s, got %s, err=%v", expected, s, err)
+	}
+
+	_, err = mgr.selectStmt(st, &accounts, nil, nil, ListOptions{OrderBy: []Order{Asc("salary")}})
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected selectStmt() returns ErrUnknownColumn for sort column out of struct, got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package dbwrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest selects a page of Page.
+type PageRequest struct {
+	// Cursor is PageResult.Next or PageResult.Prev of the previous call, empty for the first page.
+	Cursor string
+
+	// SortKey defaults to the primary key `PkName`, which is appended if missing to make the key unique.
+	// Sort columns must be `db` tags of the destination struct and must not be NULL.
+	SortKey []Order
+
+	// Size is the count of records per page, 20 if it is not greater than 0.
+	Size int
+}
+
+// PageResult holds cursors of the pages next to the returned one, empty if there is no such page.
+type PageResult struct {
+	Next string
+	Prev string
+}
+
+// cursor is encoded into the opaque token of PageResult.
+// Types tags values which JSON does not keep, see cursorValue.
+type cursor struct {
+	Values   []interface{} `json:"v"`
+	Types    []string      `json:"t,omitempty"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// Types of cursor values.
+const (
+	cursorTime = "time" // time.Time in RFC 3339
+	cursorUint = "uint" // unsigned integer beyond int64 in decimal
+)
+
+// Page query records after(or before) the cursor in order of the sort key, known as keyset pagination.
+// parameter `objs` must be pass by `&[]MyObject{}`.
+func (its *DBWrapper) Page(
+	db Executor, objs interface{},
+	columns []string,
+	where Condition,
+	req PageRequest) (PageResult, error) {
+	return its.PageContext(context.Background(), db, objs, columns, where, req)
+}
+
+// PageContext is like Page but runs with ctx.
+func (its *DBWrapper) PageContext(
+	ctx context.Context,
+	db Executor, objs interface{},
+	columns []string,
+	where Condition,
+	req PageRequest) (res PageResult, err error) {
+	rv := reflect.ValueOf(objs)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		err = fmt.Errorf("Page requires a pointer to slice, got %T", objs)
+		return
+	}
+	rows := rv.Elem()
+
+	size := req.Size
+	if size <= 0 {
+		size = 20
+	}
+
+	orders := its.sortKey(req.SortKey)
+	if len(columns) > 0 {
+		for _, o := range orders {
+			if !inStrings(columns, o.Column) {
+				columns = append(columns, o.Column)
+			}
+		}
+	}
+
+	var c cursor
+	if req.Cursor != "" {
+		c, err = decodeCursor(req.Cursor)
+		if err != nil {
+			return
+		}
+		if len(c.Values) != len(orders) {
+			err = ErrInvalidCursor
+			return
+		}
+	}
+
+	queryOrders := orders
+	if c.Backward {
+		queryOrders = make([]Order, len(orders))
+		for i, o := range orders {
+			queryOrders[i] = Order{Column: o.Column, Desc: !o.Desc}
+		}
+	}
+	if c.Values != nil {
+		where = And(where, keyset(queryOrders, c.Values))
+	}
+
+	// one more record tells if there is a page after
+	err = its.GetsWhereContext(ctx, db, objs, columns, where, size+1, ListOptions{OrderBy: queryOrders})
+	if err != nil {
+		return
+	}
+
+	more := rows.Len() > size
+	if more {
+		rows.Set(rows.Slice(0, size))
+	}
+	if c.Backward {
+		reverse(rows)
+	}
+	if rows.Len() == 0 {
+		return
+	}
+
+	first, err := encodeCursor(rows.Index(0), orders, true)
+	if err != nil {
+		return
+	}
+	last, err := encodeCursor(rows.Index(rows.Len()-1), orders, false)
+	if err != nil {
+		return
+	}
+
+	if c.Backward {
+		res.Next = last
+		if more {
+			res.Prev = first
+		}
+	} else {
+		if more {
+			res.Next = last
+		}
+		if req.Cursor != "" {
+			res.Prev = first
+		}
+	}
+	return
+}
+
+// pkName returns `PkName`, "id" if it is empty.
+func (its *DBWrapper) pkName() string {
+	if its.PkName == "" {
+		return "id"
+	}
+	return its.PkName
+}
+
+// sortKey returns orders with the primary key appended if missing.
+func (its *DBWrapper) sortKey(orders []Order) []Order {
+	pk := its.pkName()
+	if len(orders) == 0 {
+		return []Order{Asc(pk)}
+	}
+	for _, o := range orders {
+		if o.Column == pk {
+			return orders
+		}
+	}
+	key := append([]Order{}, orders...)
+	return append(key, Order{Column: pk, Desc: orders[len(orders)-1].Desc})
+}
+
+// keyset returns the condition of records after values in order of orders, e.g.
+// `a > ? OR (a = ? AND b > ?)` for a and b in ascending order.
+func keyset(orders []Order, values []interface{}) Condition {
+	ors := make([]Condition, 0, len(orders))
+	for i, o := range orders {
+		ands := make([]Condition, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, Eq(orders[j].Column, values[j]))
+		}
+		if o.Desc {
+			ands = append(ands, Lt(o.Column, values[i]))
+		} else {
+			ands = append(ands, Gt(o.Column, values[i]))
+		}
+		ors = append(ors, And(ands...))
+	}
+	return Or(ors...)
+}
+
+// encodeCursor returns the token of sort values in row.
+func encodeCursor(row reflect.Value, orders []Order, backward bool) (string, error) {
+	c := cursor{Backward: backward}
+	typed := false
+	for _, o := range orders {
+		v, ok := fieldByTag(row, o.Column)
+		if !ok {
+			return "", fmt.Errorf("sort column %s is not a `db` tag of %v", o.Column, row.Type())
+		}
+		v, typ := cursorValue(v)
+		c.Values = append(c.Values, v)
+		c.Types = append(c.Types, typ)
+		typed = typed || typ != ""
+	}
+	if !typed {
+		c.Types = nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(token string) (c cursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if c.Types != nil && len(c.Types) != len(c.Values) {
+		return c, ErrInvalidCursor
+	}
+	for i, v := range c.Values {
+		if c.Types != nil && c.Types[i] != "" {
+			if c.Values[i], err = restoreCursorValue(v, c.Types[i]); err != nil {
+				return c, ErrInvalidCursor
+			}
+			continue
+		}
+		// keep integers exact, e.g. BIGINT primary key
+		if n, ok := v.(json.Number); ok {
+			if i64, err := n.Int64(); err == nil {
+				c.Values[i] = i64
+			} else if f64, err := n.Float64(); err == nil {
+				c.Values[i] = f64
+			}
+		}
+	}
+	return c, nil
+}
+
+// cursorValue returns v and its type for values which JSON does not keep,
+// time.Time would come back as a string and uint64 beyond int64 as a lossy float64.
+func cursorValue(v interface{}) (interface{}, string) {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano), cursorTime
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return strconv.FormatUint(rv.Uint(), 10), cursorUint
+		}
+	}
+	return v, ""
+}
+
+// restoreCursorValue returns the value of typ encoded by cursorValue.
+func restoreCursorValue(v interface{}, typ string) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	switch typ {
+	case cursorTime:
+		return time.Parse(time.RFC3339Nano, s)
+	case cursorUint:
+		return strconv.ParseUint(s, 10, 64)
+	}
+	return nil, ErrInvalidCursor
+}
+
+// fieldByTag returns the value of the field tagged `db:"column"` in struct v.
+func fieldByTag(v reflect.Value, column string) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("db")
+		if strings.SplitN(tag, ",", 2)[0] == column {
+			return v.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+func reverse(rows reflect.Value) {
+	swap := reflect.Swapper(rows.Interface())
+	for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
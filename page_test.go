@@ -0,0 +1,91 @@
+package dbwrapper
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	mgr := NewAccountProxy()
+
+	orders := mgr.sortKey([]Order{Desc("mobileNo")})
+	expected := []Order{Desc("mobileNo"), Desc("id")}
+	if !reflect.DeepEqual(orders, expected) {
+		t.Errorf("expected sortKey() appends primary key, got %v", orders)
+	}
+
+	a := Account{ID: 1 << 60, MobileNo: "13800138000"}
+	token, err := encodeCursor(reflect.ValueOf(a), orders, true)
+	if err != nil {
+		t.Fatalf("expected encodeCursor() returns err == nil, got %v", err)
+	}
+	c, err := decodeCursor(token)
+	if err != nil || !c.Backward || !reflect.DeepEqual(c.Values, []interface{}{"13800138000", int64(1 << 60)}) {
+		t.Errorf("expected decodeCursor() returns values of encodeCursor(), got %v, err=%v", c, err)
+	}
+
+	type event struct {
+		At  time.Time `db:"at"`
+		Seq uint64    `db:"seq"`
+	}
+	e := event{At: time.Date(2020, 1, 1, 0, 0, 0, 1000, time.UTC), Seq: 1<<63 + 1}
+	token, err = encodeCursor(reflect.ValueOf(e), []Order{Asc("at"), Asc("seq")}, false)
+	if err != nil {
+		t.Fatalf("expected encodeCursor() returns err == nil, got %v", err)
+	}
+	c, err = decodeCursor(token)
+	if err != nil || len(c.Values) != 2 || !e.At.Equal(c.Values[0].(time.Time)) || c.Values[1] != e.Seq {
+		t.Errorf("expected decodeCursor() restores time.Time and uint64, got %#v, err=%v", c.Values, err)
+	}
+
+	if _, err = decodeCursor("!" + token); err != ErrInvalidCursor {
+		t.Errorf("expected decodeCursor() returns ErrInvalidCursor, got %v", err)
+	}
+
+	st := &stmt{d: MySQL}
+	s, err := whereClause(st, keyset(orders, c.Values))
+	if err != nil || s != "(`mobileNo` < ? OR (`mobileNo` = ? AND `id` < ?))" {
+		t.Errorf("expected keyset() returns row comparison, got %s, err=%v", s, err)
+	}
+}
+
+func TestPage(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	for i := 0; i < 5; i++ {
+		_, err := mgr.Create(db, &map[string]interface{}{
+			"mobileNo": fmt.Sprintf("1380013800%d", i),
+		})
+		if err != nil {
+			t.Fatalf("expected mgr.Create() returns err == nil, got %v", err)
+		}
+	}
+
+	req := PageRequest{Size: 2}
+	pages := [][]Account{}
+	for {
+		accounts := []Account{}
+		res, err := mgr.Page(db, &accounts, []string{"mobileNo"}, nil, req)
+		if err != nil {
+			t.Fatalf("expected mgr.Page() returns err == nil, got %v", err)
+		}
+		pages = append(pages, accounts)
+		if res.Next == "" {
+			break
+		}
+		req.Cursor = res.Next
+	}
+
+	if len(pages) != 3 || len(pages[2]) != 1 || pages[2][0].MobileNo != "13800138004" {
+		t.Errorf("expected mgr.Page() returns 3 pages ends with 13800138004, got %v", pages)
+	}
+
+	tearDown(mgr)
+}
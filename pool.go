@@ -0,0 +1,158 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sharedPool is a pool shared by wrappers of the same driver and DSN, closed when refs drops to 0.
+type sharedPool struct {
+	db   *sqlx.DB
+	refs int
+}
+
+var (
+	sharedPoolsMu sync.Mutex
+	sharedPools   = map[string]*sharedPool{}
+)
+
+// acquirePool returns the shared pool of key, it is opened by open if there is none.
+// open runs without the lock, so a slow or failing database does not block other pools.
+func acquirePool(ctx context.Context, key string, open func(ctx context.Context) (*sqlx.DB, error)) (*sqlx.DB, error) {
+	sharedPoolsMu.Lock()
+	if p := sharedPools[key]; p != nil {
+		p.refs++
+		sharedPoolsMu.Unlock()
+		return p.db, nil
+	}
+	sharedPoolsMu.Unlock()
+
+	db, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedPoolsMu.Lock()
+	p := sharedPools[key]
+	if p == nil {
+		p = &sharedPool{db: db}
+		sharedPools[key] = p
+	}
+	p.refs++
+	sharedPoolsMu.Unlock()
+
+	if p.db != db {
+		// opened concurrently by another wrapper
+		db.Close()
+	}
+	return p.db, nil
+}
+
+// releasePool drops a reference to the shared pool of key, the last one closes it.
+func releasePool(key string) error {
+	sharedPoolsMu.Lock()
+	p := sharedPools[key]
+	if p == nil {
+		sharedPoolsMu.Unlock()
+		return nil
+	}
+	p.refs--
+	if p.refs > 0 {
+		sharedPoolsMu.Unlock()
+		return nil
+	}
+	delete(sharedPools, key)
+	sharedPoolsMu.Unlock()
+	return p.db.Close()
+}
+
+// DB returns the pool of `DriverName` and `Dsn`, it is opened on first use and shared by wrappers
+// of the same driver and DSN, e.g. wrappers of different tables.
+// Non-zero tuning of the wrapper, e.g. `MaxOpenConns`, is applied to the pool when the wrapper acquires it,
+// so it overrides the tuning of wrappers which acquired it before.
+// It is safe for concurrent use, call Close to release it on shutdown.
+func (its *DBWrapper) DB(ctx context.Context) (db *sqlx.DB, err error) {
+	key := its.DriverName + "\x00" + its.Dsn
+
+	its.poolsMu.Lock()
+	db = its.pools[key]
+	its.poolsMu.Unlock()
+	if db != nil {
+		return
+	}
+
+	db, err = acquirePool(ctx, key, its.OpenDBContext)
+	if err != nil {
+		return
+	}
+
+	its.poolsMu.Lock()
+	defer its.poolsMu.Unlock()
+	if held := its.pools[key]; held != nil {
+		// acquired concurrently by another call, keep one reference
+		return held, releasePool(key)
+	}
+	if its.pools == nil {
+		its.pools = map[string]*sqlx.DB{}
+	}
+	its.pools[key] = db
+	its.tune(db)
+	return
+}
+
+// tune applies non-zero tuning of the wrapper to db.
+func (its *DBWrapper) tune(db *sqlx.DB) {
+	if its.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(its.MaxOpenConns)
+	}
+	if its.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(its.MaxIdleConns)
+	}
+	if its.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(its.ConnMaxLifetime)
+	}
+	if its.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(its.ConnMaxIdleTime)
+	}
+}
+
+// Close releases pools used by the wrapper, a pool is closed once no wrapper uses it.
+// The next call of DB opens or acquires it again.
+func (its *DBWrapper) Close() error {
+	its.poolsMu.Lock()
+	defer its.poolsMu.Unlock()
+
+	var errs []error
+	for key := range its.pools {
+		if err := releasePool(key); err != nil {
+			errs = append(errs, err)
+		}
+		delete(its.pools, key)
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns stats of pools used by the wrapper, zero before the first call of DB.
+// Wrappers sharing a pool report the same stats.
+func (its *DBWrapper) Stats() (stats sql.DBStats) {
+	its.poolsMu.Lock()
+	defer its.poolsMu.Unlock()
+
+	for _, db := range its.pools {
+		s := db.Stats()
+		stats.MaxOpenConnections += s.MaxOpenConnections
+		stats.OpenConnections += s.OpenConnections
+		stats.InUse += s.InUse
+		stats.Idle += s.Idle
+		stats.WaitCount += s.WaitCount
+		stats.WaitDuration += s.WaitDuration
+		stats.MaxIdleClosed += s.MaxIdleClosed
+		stats.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+		stats.MaxLifetimeClosed += s.MaxLifetimeClosed
+	}
+	return
+}
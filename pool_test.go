@@ -0,0 +1,109 @@
+package dbwrapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDB(t *testing.T) {
+	mgr := NewAccountProxy()
+	mgr.MaxOpenConns = 4
+	defer mgr.Close()
+
+	ctx := context.Background()
+	db, err := mgr.DB(ctx)
+	if err != nil {
+		t.Fatalf("expected mgr.DB() returns err == nil, got %v", err)
+	}
+	if stats := db.Stats(); stats.MaxOpenConnections != 4 {
+		t.Errorf("expected mgr.DB() applies MaxOpenConns 4, got %d", stats.MaxOpenConnections)
+	}
+
+	again, err := mgr.DB(ctx)
+	if err != nil || again != db {
+		t.Errorf("expected mgr.DB() returns the shared pool, got %p != %p, err=%v", again, db, err)
+	}
+
+	other := NewAccountProxy()
+	other.TableName = "test_dbwrapper_other"
+	shared, err := other.DB(ctx)
+	if err != nil || shared != db {
+		t.Errorf("expected DB() of a wrapper of the same DSN returns the shared pool, got %p != %p, err=%v", shared, db, err)
+	}
+	other.Close()
+	if err := db.PingContext(ctx); err != nil {
+		t.Errorf("expected Close() of other wrappers keeps the pool, got %v", err)
+	}
+
+	// nil db runs on the shared pool
+	a := Account{}
+	err = mgr.GetContext(ctx, nil, &a, []string{"id"}, "id", 1)
+	if err != nil && err != ErrRecordNotFound {
+		t.Errorf("expected mgr.GetContext() with nil db returns err == nil or ErrRecordNotFound, got %v", err)
+	}
+
+	err = mgr.Close()
+	if err != nil {
+		t.Errorf("expected mgr.Close() returns err == nil, got %v", err)
+	}
+	again, err = mgr.DB(ctx)
+	if err != nil || again == db {
+		t.Errorf("expected mgr.DB() returns a new pool after Close(), err=%v", err)
+	}
+}
+
+func TestSharedPool(t *testing.T) {
+	opened := 0
+	open := func(ctx context.Context) (*sqlx.DB, error) {
+		opened++
+		return sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	}
+
+	ctx := context.Background()
+	key := "mysql\x00TestSharedPool"
+	a, err := acquirePool(ctx, key, open)
+	if err != nil {
+		t.Fatalf("expected acquirePool() returns err == nil, got %v", err)
+	}
+	b, _ := acquirePool(ctx, key, open)
+	if a != b || opened != 1 {
+		t.Errorf("expected acquirePool() shares the pool, got %p != %p, opened %d", a, b, opened)
+	}
+
+	releasePool(key)
+	if err := a.Ping(); err != nil && err.Error() == "sql: database is closed" {
+		t.Errorf("expected releasePool() keeps the pool referenced by others")
+	}
+	releasePool(key)
+	if err := a.Ping(); err == nil || err.Error() != "sql: database is closed" {
+		t.Errorf("expected releasePool() closes the pool of the last reference, got %v", err)
+	}
+	if _, ok := sharedPools[key]; ok {
+		t.Errorf("expected releasePool() removes the pool")
+	}
+}
+
+func TestDBTuning(t *testing.T) {
+	mgr := &DBWrapper{DriverName: "mysql", Dsn: "root@tcp(127.0.0.1:1)/TestDBTuning", MaxOpenConns: 4}
+	key := mgr.DriverName + "\x00" + mgr.Dsn
+
+	// opened by another wrapper of the same DSN
+	opened, _ := sqlx.Open("mysql", mgr.Dsn)
+	opened.SetMaxOpenConns(10)
+	sharedPoolsMu.Lock()
+	sharedPools[key] = &sharedPool{db: opened, refs: 1}
+	sharedPoolsMu.Unlock()
+
+	db, err := mgr.DB(context.Background())
+	if err != nil || db != opened {
+		t.Fatalf("expected mgr.DB() acquires the shared pool, err=%v", err)
+	}
+	if n := db.Stats().MaxOpenConnections; n != 4 {
+		t.Errorf("expected mgr.DB() applies MaxOpenConns 4 to the shared pool, got %d", n)
+	}
+
+	mgr.Close()
+	releasePool(key)
+}
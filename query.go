@@ -0,0 +1,160 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// runFunc runs the rendered statement s, result is nil for queries.
+type runFunc func(ctx context.Context, s string, args []interface{}) (sql.Result, error)
+
+// run runs the statement of info by fn within a span and hooks,
+// its error is classified by d and wrapped into *QueryError.
+// Every statement of DBWrapper methods goes through it.
+func (its *DBWrapper) run(ctx context.Context, d Dialect, info *QueryInfo, fn runFunc) (result sql.Result, err error) {
+	info.Table = its.TableName
+	ctx, span := its.tracer().Start(ctx, info.Op+" "+info.Table)
+	defer span.End()
+
+	ctx, n, err := its.beforeQuery(ctx, info)
+
+	start := time.Now()
+	switch {
+	case err != nil:
+	case info.Skip:
+		result = info.Result
+	default:
+		result, err = fn(ctx, info.SQL, info.Args)
+	}
+	elapsed := time.Since(start)
+	rows := rowCount(info, result, err)
+
+	if its.SlowQueryThreshold > 0 && elapsed >= its.SlowQueryThreshold {
+		its.logWarn(ctx, "slow query",
+			"op", info.Op,
+			"table", info.Table,
+			"fingerprint", fingerprint(info.SQL),
+			"args", logArgs(its.redactArgs(info)),
+			"rows", rows,
+			"elapsed", elapsed,
+		)
+	}
+
+	err = classifyError(d, err)
+	if err == nil || err == ErrRecordNotFound {
+		its.logDebug(ctx, "sql", "op", info.Op, "sql", info.SQL, "args", logArgs(its.redactArgs(info)), "elapsed", elapsed)
+	} else {
+		err = &QueryError{
+			Op:      info.Op,
+			Table:   info.Table,
+			SQL:     info.SQL,
+			Args:    its.errorArgs(info),
+			Elapsed: elapsed,
+			Err:     err,
+		}
+		if handledError(err) {
+			its.logDebug(ctx, "query failed", "op", info.Op, "table", info.Table, "error", err)
+		} else {
+			its.logError(ctx, "query failed", "op", info.Op, "table", info.Table, "error", err)
+		}
+	}
+
+	span.SetAttribute(AttrDBSystem, d.Name())
+	span.SetAttribute(AttrDBOperation, info.Op)
+	span.SetAttribute(AttrDBTable, info.Table)
+	span.SetAttribute(AttrDBStatement, fingerprint(info.SQL))
+	if rows >= 0 {
+		span.SetAttribute(AttrRowsAffected, rows)
+	}
+	if class := errorClass(err); class != "" {
+		span.SetAttribute(AttrErrorClass, class)
+		span.RecordError(err)
+	}
+
+	its.afterQuery(ctx, n, info, result, err)
+	return
+}
+
+// exec runs statement s with args of st on db.
+func (its *DBWrapper) exec(ctx context.Context, db Executor, op string, st *stmt, s string) (sql.Result, error) {
+	info := &QueryInfo{Op: op, SQL: s, Args: st.args, columns: st.argColumns}
+	return its.run(ctx, st.d, info, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return db.ExecContext(ctx, s, args...)
+	})
+}
+
+// selectRows scans rows of query s into dest, a pointer to slice.
+func (its *DBWrapper) selectRows(ctx context.Context, db Executor, op string, st *stmt, dest interface{}, s string) (err error) {
+	info := &QueryInfo{Op: op, SQL: s, Args: st.args, Dest: dest, columns: st.argColumns}
+	_, err = its.run(ctx, st.d, info, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, db.SelectContext(ctx, dest, s, args...)
+	})
+	return
+}
+
+// getRow scans one row of query s into dest, no row is ErrRecordNotFound.
+func (its *DBWrapper) getRow(ctx context.Context, db Executor, op string, st *stmt, dest interface{}, s string) (err error) {
+	info := &QueryInfo{Op: op, SQL: s, Args: st.args, Dest: dest, columns: st.argColumns}
+	_, err = its.run(ctx, st.d, info, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		err := db.GetContext(ctx, dest, s, args...)
+		if err == sql.ErrNoRows {
+			err = ErrRecordNotFound
+		}
+		return nil, err
+	})
+	return
+}
+
+// errorArgs returns args carried by QueryError, nil if `RedactArgs` is set.
+func (its *DBWrapper) errorArgs(info *QueryInfo) []interface{} {
+	if its.RedactArgs {
+		return nil
+	}
+	return its.redactArgs(info)
+}
+
+var (
+	literalPattern     = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+	spacePattern       = regexp.MustCompile(`\s+`)
+	placeholderPattern = regexp.MustCompile(`\(\?(?:\s*,\s*\?)*\)`)
+	rowsPattern        = regexp.MustCompile(`\(\?\+\)(?:\s*,\s*\(\?\+\))+`)
+)
+
+// fingerprint returns s with literals and placeholders replaced by `?`,
+// lists of placeholders collapsed into `(?+)` and rows of a bulk insert into `(?+),...`,
+// so statements which differ only by values have the same fingerprint.
+func fingerprint(s string) string {
+	s = literalPattern.ReplaceAllString(s, "?")
+	s = spacePattern.ReplaceAllString(strings.TrimSpace(s), " ")
+	s = placeholderPattern.ReplaceAllString(s, "(?+)")
+	return rowsPattern.ReplaceAllString(s, "(?+),...")
+}
+
+// rowCount returns rows affected of result, or rows scanned into info.Dest, -1 if unknown.
+func rowCount(info *QueryInfo, result sql.Result, err error) int64 {
+	if err != nil {
+		return -1
+	}
+	if result != nil {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	if info.Dest == nil {
+		return -1
+	}
+	v := reflect.ValueOf(info.Dest)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		return int64(v.Len())
+	}
+	return 1
+}
@@ -0,0 +1,53 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM `t` WHERE `id` = ? LIMIT 1":                       "SELECT * FROM `t` WHERE `id` = ? LIMIT ?",
+		`SELECT * FROM "t" WHERE "id" = $1 AND "name" = 'a''b'`:          `SELECT * FROM "t" WHERE "id" = ? AND "name" = ?`,
+		"SELECT * FROM `t` WHERE `id` IN (?, ?,?)":                       "SELECT * FROM `t` WHERE `id` IN (?+)",
+		"INSERT INTO `t` (`a`,`b`) VALUES (?,?),(?,?),(?,?)":             "INSERT INTO `t` (`a`,`b`) VALUES (?+),...",
+		"UPDATE `t`\n\tSET `a`=?   WHERE `id2` = 10":                     "UPDATE `t` SET `a`=? WHERE `id2` = ?",
+		`INSERT INTO "t" ("a") VALUES ($1) ON CONFLICT ("a") DO NOTHING`: `INSERT INTO "t" ("a") VALUES (?+) ON CONFLICT ("a") DO NOTHING`,
+	}
+	for s, expected := range cases {
+		if got := fingerprint(s); got != expected {
+			t.Errorf("expected fingerprint(%q) returns %q, got %q", s, expected, got)
+		}
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	l := &recordLogger{}
+	mgr := &DBWrapper{TableName: "test_dbwrapper", Logger: l, SlowQueryThreshold: time.Millisecond}
+	objs := []Account{{}, {}}
+	slow := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		time.Sleep(2 * time.Millisecond)
+		return nil, nil
+	}
+	fast := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) { return nil, nil }
+
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "GetsWhere", SQL: "SELECT * FROM `t` WHERE `id` IN (?,?)", Dest: &objs}, fast)
+	if len(l.entries) != 0 {
+		t.Errorf("expected no output of fast queries, got %v", l.entries)
+	}
+
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "GetsWhere", SQL: "SELECT * FROM `t` WHERE `id` IN (?,?)", Dest: &objs}, slow)
+	if len(l.entries) != 1 || l.entries[0].level != slog.LevelWarn {
+		t.Fatalf("expected a warn entry of the slow query, got %v", l.entries)
+	}
+	attrs := map[interface{}]interface{}{}
+	for i := 0; i+1 < len(l.entries[0].args); i += 2 {
+		attrs[l.entries[0].args[i]] = l.entries[0].args[i+1]
+	}
+	if attrs["fingerprint"] != "SELECT * FROM `t` WHERE `id` IN (?+)" || attrs["rows"] != int64(2) {
+		t.Errorf("expected fingerprint and rows of the slow query, got %v", attrs)
+	}
+}
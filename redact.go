@@ -0,0 +1,70 @@
+package dbwrapper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// redacted replaces args of secret columns in logs and errors.
+const redacted = "[redacted]"
+
+// SecretColumns returns columns tagged `dbw:"secret"` in struct obj, e.g. `db:"password" dbw:"secret"`.
+func (its *DBWrapper) SecretColumns(obj interface{}) []string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	columns := []string{}
+	for _, f := range structFields(t) {
+		if f.secret {
+			columns = append(columns, f.name)
+		}
+	}
+	return columns
+}
+
+// modelSecrets returns secret columns of `Model`, they are cached until Model is changed to another type.
+func (its *DBWrapper) modelSecrets() []string {
+	if its.Model == nil {
+		return nil
+	}
+	t := reflect.TypeOf(its.Model)
+
+	its.secretsMu.Lock()
+	defer its.secretsMu.Unlock()
+	if its.secretsModel != t {
+		its.secretsModel, its.secrets = t, its.SecretColumns(its.Model)
+	}
+	return its.secrets
+}
+
+// isSecret reports whether args of column are redacted, column may be qualified by table.
+func (its *DBWrapper) isSecret(column string) bool {
+	if column == "" {
+		return false
+	}
+	column = column[strings.LastIndexByte(column, '.')+1:]
+	return inStrings(its.RedactColumns, column) || inStrings(its.modelSecrets(), column)
+}
+
+// redactArgs returns args of info with the ones of secret columns replaced.
+func (its *DBWrapper) redactArgs(info *QueryInfo) []interface{} {
+	var args []interface{}
+	for i, column := range info.columns {
+		if i >= len(info.Args) || !its.isSecret(column) {
+			continue
+		}
+		if args == nil {
+			args = append([]interface{}{}, info.Args...)
+		}
+		args[i] = redacted
+	}
+	if args == nil {
+		return info.Args
+	}
+	return args
+}
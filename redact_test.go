@@ -0,0 +1,85 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSecretColumns(t *testing.T) {
+	mgr := &DBWrapper{}
+	if columns := fmt.Sprint(mgr.SecretColumns(&[]Account{})); columns != "[password]" {
+		t.Errorf("expected SecretColumns() returns [password], got %s", columns)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	l := &recordLogger{}
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "test_dbwrapper", Debug: true, Logger: l, RedactColumns: []string{"token"}}
+	fail := func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, errors.New("boom")
+	}
+
+	st, _ := mgr.newStmt()
+	s, _ := mgr.insertStmt(st, map[string]interface{}{"mobileNo": "13800138000", "password": "123456", "token": "abc"})
+	info := &QueryInfo{Op: "Create", SQL: s, Args: st.args, columns: st.argColumns}
+
+	_, err := mgr.run(context.Background(), MySQL, info, fail)
+	queryError := &QueryError{}
+	errors.As(err, &queryError)
+	if args := fmt.Sprint(queryError.Args); args != "[13800138000 123456 [redacted]]" {
+		t.Errorf("expected args of RedactColumns are redacted, got %s", args)
+	}
+
+	// secret columns of Model
+	mgr.Model = &Account{}
+	l.entries = nil
+	_, err = mgr.run(context.Background(), MySQL, info, fail)
+	errors.As(err, &queryError)
+	if args := fmt.Sprint(queryError.Args); args != "[13800138000 [redacted] [redacted]]" {
+		t.Errorf("expected args of secret columns are redacted, got %s", args)
+	}
+	if strings.Contains(fmt.Sprint(l.entries), "123456") {
+		t.Errorf("expected logs are redacted, got %v", l.entries)
+	}
+	if info.Args[1] != "123456" {
+		t.Errorf("expected the statement runs with origin args, got %v", info.Args)
+	}
+
+	st, _ = mgr.newStmt()
+	w, _ := whereClause(st, And(Eq("mobileNo", "13800138000"), Eq("test_dbwrapper.password", "123456")))
+	l.entries = nil
+	mgr.run(context.Background(), MySQL, &QueryInfo{Op: "GetsWhere", SQL: w, Args: st.args, columns: st.argColumns}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, nil
+	})
+	if strings.Contains(fmt.Sprint(l.entries), "123456") {
+		t.Errorf("expected args of conditions are redacted, got %v", l.entries)
+	}
+}
+
+func TestRedactModel(t *testing.T) {
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	l := &recordLogger{}
+	mgr := NewAccountProxy()
+	mgr.Logger = l
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, nil
+	}}}
+
+	// the first statement of a fresh wrapper, from a map
+	_, err := mgr.Create(db, &map[string]interface{}{"mobileNo": "13800138000", "password": "hunter2"})
+	if err != nil {
+		t.Fatalf("expected mgr.Create() returns err == nil, got %v", err)
+	}
+	if len(l.entries) == 0 || strings.Contains(fmt.Sprint(l.entries), "hunter2") {
+		t.Errorf("expected password is redacted, got %v", l.entries)
+	}
+}
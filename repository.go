@@ -0,0 +1,83 @@
+package dbwrapper
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Repository is a typed accessor of the table of DBWrapper whose records are T.
+// Columns are `db` tags of T, the primary key is the field tagged `db:"name,pk"`, or `PkName` of the wrapper.
+type Repository[T any] struct {
+	Wrapper *DBWrapper
+
+	db      Executor
+	columns []string
+	pk      field
+}
+
+// NewRepository returns a repository of T on the table of w, statements run on the shared pool of w.
+// It sets `Model` of w to T if it is nil.
+// It panics if T is not a struct or has no primary key field.
+func NewRepository[T any](w *DBWrapper) *Repository[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("dbwrapper: Repository requires a struct, got %v", t))
+	}
+
+	if w.Model == nil {
+		// secret columns of T are redacted
+		w.Model = reflect.New(t).Interface()
+	}
+	fields := structFields(t)
+	pk, ok := pkField(fields, w.pkName())
+	if !ok {
+		panic(fmt.Sprintf("dbwrapper: %v has no primary key field %s", t, w.pkName()))
+	}
+
+	r := &Repository[T]{Wrapper: w, pk: pk}
+	for _, f := range fields {
+		r.columns = append(r.columns, f.name)
+	}
+	return r
+}
+
+// With returns a copy of the repository which runs statements on db, e.g. a *sqlx.Tx.
+func (r *Repository[T]) With(db Executor) *Repository[T] {
+	c := *r
+	c.db = db
+	return &c
+}
+
+// Get returns the record of primary key pk, ErrRecordNotFound if there is none.
+func (r *Repository[T]) Get(ctx context.Context, pk interface{}) (obj T, err error) {
+	err = r.Wrapper.GetContext(ctx, r.db, &obj, r.columns, r.pk.name, pk)
+	return
+}
+
+// List returns all records matched by conds.
+func (r *Repository[T]) List(ctx context.Context, conds ...Condition) (objs []T, err error) {
+	objs = []T{}
+	err = r.Wrapper.GetsWhereContext(ctx, r.db, &objs, r.columns, And(conds...), 0)
+	return
+}
+
+// Insert creates the record of obj, a zero primary key is generated by the database and populated back into obj.
+// See also CreateStruct.
+func (r *Repository[T]) Insert(ctx context.Context, obj *T) error {
+	_, err := r.Wrapper.CreateStructContext(ctx, r.db, obj)
+	return err
+}
+
+// Update saves columns of obj into the record of its primary key, see also UpdateStruct.
+func (r *Repository[T]) Update(ctx context.Context, obj *T) error {
+	_, err := r.Wrapper.UpdateStructContext(ctx, r.db, obj)
+	return err
+}
+
+// Delete deletes the record of primary key pk.
+func (r *Repository[T]) Delete(ctx context.Context, pk interface{}) error {
+	return r.Wrapper.DelContext(ctx, r.db, r.pk.name, &map[string]interface{}{
+		r.pk.name: pk,
+	})
+}
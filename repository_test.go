@@ -0,0 +1,64 @@
+package dbwrapper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewRepository(t *testing.T) {
+	r := NewRepository[Account](&NewAccountProxy().DBWrapper)
+	if r.pk.name != "id" || len(r.columns) != 5 {
+		t.Errorf("expected NewRepository() derives pk id and 5 columns, got %s %v", r.pk.name, r.columns)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected NewRepository() panics for non-struct, got none")
+		}
+	}()
+	NewRepository[int](&NewAccountProxy().DBWrapper)
+}
+
+func TestRepository(t *testing.T) {
+	mgr := NewAccountProxy()
+	defer mgr.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	ctx := context.Background()
+	accounts := NewRepository[Account](&mgr.DBWrapper)
+
+	a := Account{MobileNo: "13800138000"}
+	err := accounts.Insert(ctx, &a)
+	if err != nil || a.ID == 0 {
+		t.Fatalf("expected Repository.Insert() populates id, got %d, err=%v", a.ID, err)
+	}
+
+	b, err := accounts.Get(ctx, a.ID)
+	if err != nil || b.MobileNo != a.MobileNo {
+		t.Errorf("expected Repository.Get() returns %s, got %v, err=%v", a.MobileNo, b, err)
+	}
+
+	b.Password = "secret"
+	err = accounts.Update(ctx, &b)
+	if err != nil {
+		t.Errorf("expected Repository.Update() returns err == nil, got %v", err)
+	}
+
+	list, err := accounts.List(ctx, Eq("password", "secret"))
+	if err != nil || len(list) != 1 || list[0].ID != a.ID {
+		t.Errorf("expected Repository.List() returns the updated record, got %v, err=%v", list, err)
+	}
+
+	err = accounts.Delete(ctx, a.ID)
+	if err != nil {
+		t.Errorf("expected Repository.Delete() returns err == nil, got %v", err)
+	}
+	_, err = accounts.Get(ctx, a.ID)
+	if err != ErrRecordNotFound {
+		t.Errorf("expected Repository.Get() returns ErrRecordNotFound, got %v", err)
+	}
+
+	tearDown(mgr)
+}
@@ -0,0 +1,103 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// deletedScope selects records by `SoftDeleteColumn`, it is ignored if soft delete is disabled.
+type deletedScope int
+
+const (
+	excludeDeleted deletedScope = iota
+	withDeleted
+	onlyDeleted
+)
+
+// WithDeleted returns ListOptions which select soft-deleted records as well, e.g.
+//
+//	w.GetsWhere(db, &accounts, nil, nil, 10, dbwrapper.WithDeleted())
+func WithDeleted() ListOptions { return ListOptions{deleted: withDeleted} }
+
+// OnlyDeleted returns ListOptions which select soft-deleted records only.
+func OnlyDeleted() ListOptions { return ListOptions{deleted: onlyDeleted} }
+
+// softDeleteScope adds the condition of `SoftDeleteColumn` selected by scope to where.
+func (its *DBWrapper) softDeleteScope(where Condition, scope deletedScope) Condition {
+	if its.SoftDeleteColumn == "" {
+		return where
+	}
+	switch scope {
+	case withDeleted:
+		return where
+	case onlyDeleted:
+		return And(where, IsNotNull(its.SoftDeleteColumn))
+	}
+	return And(where, IsNull(its.SoftDeleteColumn))
+}
+
+// Restore undelete soft-deleted records matched by where conditions, it returns the count of restored records.
+// It requires `SoftDeleteColumn`.
+func (its *DBWrapper) Restore(db Executor, where Condition) (rows int64, err error) {
+	return its.RestoreContext(context.Background(), db, where)
+}
+
+// RestoreContext is like Restore but runs with ctx.
+func (its *DBWrapper) RestoreContext(ctx context.Context, db Executor, where Condition) (rows int64, err error) {
+	if its.SoftDeleteColumn == "" {
+		err = errors.New("Restore requires SoftDeleteColumn")
+		return
+	}
+	if where == nil {
+		err = errors.New("Restore requires conditions")
+		return
+	}
+
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	s, err := its.restoreStmt(st, where)
+	if err != nil {
+		return
+	}
+
+	result, err := its.exec(ctx, db, "Restore", st, s)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// restoreStmt renders UPDATE which sets `SoftDeleteColumn` of soft-deleted records matched by where to NULL.
+func (its *DBWrapper) restoreStmt(st *stmt, where Condition) (string, error) {
+	column, err := st.column(its.SoftDeleteColumn)
+	if err != nil {
+		return "", err
+	}
+	w, err := whereClause(st, And(where, IsNotNull(its.SoftDeleteColumn)))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("UPDATE %s SET %s=NULL WHERE %s", st.table, column, w), nil
+}
+
+// ForceDelete delete records matched by where conditions even if `SoftDeleteColumn` is set,
+// soft-deleted records are included. It returns the count of deleted records.
+func (its *DBWrapper) ForceDelete(db Executor, where Condition) (rows int64, err error) {
+	return its.ForceDeleteContext(context.Background(), db, where)
+}
+
+// ForceDeleteContext is like ForceDelete but runs with ctx.
+func (its *DBWrapper) ForceDeleteContext(ctx context.Context, db Executor, where Condition) (rows int64, err error) {
+	return its.deleteWhere(ctx, db, "ForceDelete", where, 0, true)
+}
@@ -0,0 +1,67 @@
+package dbwrapper
+
+import (
+	"testing"
+)
+
+func TestSoftDeleteStmt(t *testing.T) {
+	cases := []struct {
+		driverName string
+		limit      int
+		force      bool
+		expected   string
+	}{
+		{"mysql", 0, false, "UPDATE `test_dbwrapper` SET `deleted_at`=? WHERE (`created` < ? AND `deleted_at` IS NULL)"},
+		{"mysql", 100, false, "UPDATE `test_dbwrapper` SET `deleted_at`=? WHERE (`created` < ? AND `deleted_at` IS NULL) LIMIT 100"},
+		{"mysql", 0, true, "DELETE FROM `test_dbwrapper` WHERE `created` < ?"},
+		{"postgres", 0, false, `UPDATE "test_dbwrapper" SET "deleted_at"=$1 WHERE ("created" < $2 AND "deleted_at" IS NULL)`},
+		{"postgres", 100, false, `UPDATE "test_dbwrapper" SET "deleted_at"=$1 WHERE ctid IN (SELECT ctid FROM "test_dbwrapper" WHERE ("created" < $2 AND "deleted_at" IS NULL) LIMIT 100)`},
+	}
+	for _, c := range cases {
+		mgr := &DBWrapper{DriverName: c.driverName, TableName: "test_dbwrapper", SoftDeleteColumn: "deleted_at"}
+		st, _ := mgr.newStmt()
+		s, err := mgr.deleteStmt(st, Lt("created", "2020-01-01"), c.limit, c.force)
+		if err != nil || s != c.expected {
+			t.Errorf("expected deleteStmt() returns %s, got %s, err=%v", c.expected, s, err)
+		}
+	}
+
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "test_dbwrapper", SoftDeleteColumn: "deleted_at"}
+	st, _ := mgr.newStmt()
+	s, err := mgr.restoreStmt(st, Eq("id", 1))
+	expected := "UPDATE `test_dbwrapper` SET `deleted_at`=NULL WHERE (`id` = ? AND `deleted_at` IS NOT NULL)"
+	if err != nil || s != expected {
+		t.Errorf("expected restoreStmt() returns %s, got %s, err=%v", expected, s, err)
+	}
+
+	if _, err := (&DBWrapper{DriverName: "mysql"}).Restore(nil, Eq("id", 1)); err == nil {
+		t.Errorf("expected Restore() requires SoftDeleteColumn")
+	}
+}
+
+func TestSoftDeleteScope(t *testing.T) {
+	cases := []struct {
+		opts     []ListOptions
+		expected string
+	}{
+		{nil, "SELECT * FROM `test_dbwrapper` WHERE (`id` > ? AND `deleted_at` IS NULL) LIMIT 10"},
+		{[]ListOptions{WithDeleted()}, "SELECT * FROM `test_dbwrapper` WHERE `id` > ? LIMIT 10"},
+		{[]ListOptions{OnlyDeleted()}, "SELECT * FROM `test_dbwrapper` WHERE (`id` > ? AND `deleted_at` IS NOT NULL) LIMIT 10"},
+		{[]ListOptions{OnlyDeleted(), {Offset: 5}}, "SELECT * FROM `test_dbwrapper` WHERE (`id` > ? AND `deleted_at` IS NOT NULL) LIMIT 10 OFFSET 5"},
+	}
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "test_dbwrapper", SoftDeleteColumn: "deleted_at"}
+	for _, c := range cases {
+		st, _ := mgr.newStmt()
+		s, err := mgr.selectStmt(st, nil, nil, Gt("id", 0), listOptions(10, c.opts))
+		if err != nil || s != c.expected {
+			t.Errorf("expected selectStmt() returns %s, got %s, err=%v", c.expected, s, err)
+		}
+	}
+
+	mgr.SoftDeleteColumn = ""
+	st, _ := mgr.newStmt()
+	s, _ := mgr.selectStmt(st, nil, nil, Gt("id", 0), listOptions(10, nil))
+	if expected := "SELECT * FROM `test_dbwrapper` WHERE `id` > ? LIMIT 10"; s != expected {
+		t.Errorf("expected selectStmt() returns %s, got %s", expected, s)
+	}
+}
@@ -0,0 +1,328 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// field is a column mapped from a struct field by tag `db:"name,option,..."`.
+// Options are
+//
+//	pk        the primary key, a zero value is left to the database(auto increment) on insert
+//	omitempty the column is not written if the value is zero
+//	readonly  the column is never written, e.g. `created` filled by the database
+//
+// Tag `dbw:"secret"` marks a column whose args are redacted, see DBWrapper.Model.
+type field struct {
+	name      string
+	index     int
+	pk        bool
+	omitempty bool
+	readonly  bool
+	secret    bool
+}
+
+// structFields returns columns of struct t, fields without tag `db` or tagged `db:"-"` are skipped.
+func structFields(t reflect.Type) []field {
+	fields := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		parts := strings.Split(t.Field(i).Tag.Get("db"), ",")
+		if parts[0] == "" || parts[0] == "-" {
+			continue
+		}
+
+		f := field{name: parts[0], index: i}
+		for _, opt := range parts[1:] {
+			switch strings.TrimSpace(opt) {
+			case "pk":
+				f.pk = true
+			case "omitempty":
+				f.omitempty = true
+			case "readonly":
+				f.readonly = true
+			}
+		}
+		for _, opt := range strings.Split(t.Field(i).Tag.Get("dbw"), ",") {
+			if strings.TrimSpace(opt) == "secret" {
+				f.secret = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// pkField returns the field of option `pk`, or the one named pkName.
+func pkField(fields []field, pkName string) (field, bool) {
+	for _, f := range fields {
+		if f.pk {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if f.name == pkName {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// structValue returns the struct obj points to.
+func structValue(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("a pointer to struct is required, got %T", obj)
+	}
+	return v.Elem(), nil
+}
+
+// structMap returns columns to write of struct v, readonly columns and zero omitempty columns are skipped.
+// The primary key is skipped if it is zero.
+func (its *DBWrapper) structMap(v reflect.Value) (m map[string]interface{}, pk field, err error) {
+	fields := structFields(v.Type())
+	pk, ok := pkField(fields, its.pkName())
+	if !ok {
+		err = fmt.Errorf("%v has no primary key field %s", v.Type(), its.pkName())
+		return
+	}
+
+	m = make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		if f.readonly || ((f.omitempty || f.name == pk.name) && fv.IsZero()) {
+			continue
+		}
+		m[f.name] = fv.Interface()
+	}
+	return
+}
+
+// returner is implemented by dialects which return the inserted primary key by `INSERT ... RETURNING`.
+type returner interface {
+	Returning(column string) string
+}
+
+// insertResult is the result of `INSERT ... RETURNING`.
+type insertResult int64
+
+func (r insertResult) LastInsertId() (int64, error) { return int64(r), nil }
+
+func (r insertResult) RowsAffected() (int64, error) { return 1, nil }
+
+// CreateStruct insert one record from tag `db` of struct obj,
+// a zero primary key is generated by the database and populated back into obj.
+// parameter `obj` must be pass by `&MyObject{}`.
+func (its *DBWrapper) CreateStruct(db Executor, obj interface{}) (result sql.Result, err error) {
+	return its.CreateStructContext(context.Background(), db, obj)
+}
+
+// CreateStructContext is like CreateStruct but runs with ctx.
+func (its *DBWrapper) CreateStructContext(ctx context.Context, db Executor, obj interface{}) (result sql.Result, err error) {
+	v, err := structValue(obj)
+	if err != nil {
+		return
+	}
+	m, pk, err := its.structMap(v)
+	if err != nil {
+		return
+	}
+	pkValue := v.Field(pk.index)
+
+	d, err := its.Dialect()
+	if err != nil {
+		return
+	}
+	r, ok := d.(returner)
+	if !ok || !pkValue.IsZero() {
+		result, err = its.CreateContext(ctx, db, &m)
+		if err != nil || !pkValue.IsZero() {
+			return
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		setInt(pkValue, id)
+		return result, nil
+	}
+
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	s, err := its.insertStmt(st, its.withTimestamps(m, its.now(), true))
+	if err != nil {
+		return
+	}
+	pkColumn, err := st.column(pk.name)
+	if err != nil {
+		return
+	}
+	s = clause(s, r.Returning(pkColumn))
+
+	var id int64
+	info := &QueryInfo{Op: "CreateStruct", SQL: s, Args: st.args, Dest: &id, columns: st.argColumns}
+	_, err = its.run(ctx, st.d, info, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, db.QueryRowxContext(ctx, s, args...).Scan(&id)
+	})
+	if err != nil {
+		return
+	}
+	setInt(pkValue, id)
+	return insertResult(id), nil
+}
+
+// CreateOrUpdateStruct insert record or update record from tag `db` of struct obj,
+// the conflict is detected on `ConflictKeys`, or the primary key if it is empty.
+// parameter `obj` must be pass by `&MyObject{}`.
+func (its *DBWrapper) CreateOrUpdateStruct(db Executor, obj interface{}) (result sql.Result, err error) {
+	return its.CreateOrUpdateStructContext(context.Background(), db, obj)
+}
+
+// CreateOrUpdateStructContext is like CreateOrUpdateStruct but runs with ctx.
+func (its *DBWrapper) CreateOrUpdateStructContext(ctx context.Context, db Executor, obj interface{}) (result sql.Result, err error) {
+	v, err := structValue(obj)
+	if err != nil {
+		return
+	}
+	m, pk, err := its.structMap(v)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	conflictKeys := its.ConflictKeys
+	if len(conflictKeys) == 0 {
+		conflictKeys = []string{pk.name}
+	}
+	s, err := its.upsertStmt(st, its.withTimestamps(m, its.now(), true), conflictKeys)
+	if err != nil {
+		return
+	}
+
+	result, err = its.exec(ctx, db, "CreateOrUpdateStruct", st, s)
+	if err != nil {
+		return
+	}
+
+	if pkValue := v.Field(pk.index); pkValue.IsZero() {
+		// MySQL returns 0 if the record was updated
+		if id, errID := result.LastInsertId(); errID == nil && id > 0 {
+			setInt(pkValue, id)
+		}
+	}
+	return
+}
+
+// UpdateStruct update the record of the primary key from tag `db` of struct obj.
+// parameter `obj` must be pass by `&MyObject{}`.
+func (its *DBWrapper) UpdateStruct(db Executor, obj interface{}) (result sql.Result, err error) {
+	return its.UpdateStructContext(context.Background(), db, obj)
+}
+
+// UpdateStructContext is like UpdateStruct but runs with ctx.
+func (its *DBWrapper) UpdateStructContext(ctx context.Context, db Executor, obj interface{}) (result sql.Result, err error) {
+	v, err := structValue(obj)
+	if err != nil {
+		return
+	}
+	m, pk, err := its.structMap(v)
+	if err != nil {
+		return
+	}
+	if _, ok := m[pk.name]; !ok {
+		err = errors.New("UpdateStruct requires a non-zero primary key")
+		return
+	}
+	return its.UpdateContext(ctx, db, pk.name, m)
+}
+
+// CreatesStruct insert records in bulk from tag `db` of structs,
+// `omitempty` is not applied because records must have the same columns.
+// The primary key is left to the database if it is zero in the first record, then it must be zero in every record,
+// otherwise it must be set in every record. Generated primary keys are not populated back into objs,
+// reload records if they are needed.
+// parameter `objs` must be pass by `&[]MyObject{}` or `&[]*MyObject{}`.
+func (its *DBWrapper) CreatesStruct(db Executor, objs interface{}) (result sql.Result, err error) {
+	return its.CreatesStructContext(context.Background(), db, objs)
+}
+
+// CreatesStructContext is like CreatesStruct but runs with ctx.
+func (its *DBWrapper) CreatesStructContext(ctx context.Context, db Executor, objs interface{}) (result sql.Result, err error) {
+	rv := reflect.ValueOf(objs)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice || rv.Len() == 0 {
+		err = fmt.Errorf("CreatesStruct requires a non-empty slice of struct, got %T", objs)
+		return
+	}
+
+	items := make([]map[string]interface{}, 0, rv.Len())
+	// whether primary keys are generated, decided by the first record
+	generated := false
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i)
+		for v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			err = fmt.Errorf("CreatesStruct requires a slice of struct, got %T", objs)
+			return
+		}
+
+		fields := structFields(v.Type())
+		pk, hasPK := pkField(fields, its.pkName())
+		if hasPK {
+			zero := v.Field(pk.index).IsZero()
+			if i == 0 {
+				generated = zero
+			} else if zero != generated {
+				err = errors.New("CreatesStruct requires primary keys of all records to be zero, or none of them")
+				return
+			}
+		}
+		m := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if f.readonly || (hasPK && generated && f.name == pk.name) {
+				continue
+			}
+			m[f.name] = v.Field(f.index).Interface()
+		}
+		items = append(items, m)
+	}
+	return its.CreatesContext(ctx, db, &items)
+}
+
+// setInt sets an integer field to id, other kinds are left as is.
+func setInt(v reflect.Value, id int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	}
+}
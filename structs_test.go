@@ -0,0 +1,108 @@
+package dbwrapper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TaggedAccount struct {
+	ID           uint64    `db:"id,pk"`
+	MobileNo     string    `db:"mobileNo"`
+	Password     string    `db:"password,omitempty"`
+	Created      time.Time `db:"created,readonly"`
+	LastModified time.Time `db:"lastModified,readonly"`
+	Note         string    `db:"-"`
+}
+
+func TestStructMap(t *testing.T) {
+	mgr := NewAccountProxy()
+	mgr.PkName = "no_such_pk"
+
+	a := TaggedAccount{MobileNo: "13800138000", Note: "note"}
+	m, pk, err := mgr.structMap(reflect.ValueOf(a))
+	expected := map[string]interface{}{"mobileNo": "13800138000"}
+	if err != nil || pk.name != "id" || !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected structMap() returns %v and pk id, got %v %s, err=%v", expected, m, pk.name, err)
+	}
+
+	a.ID = 1
+	a.Password = "secret"
+	m, _, _ = mgr.structMap(reflect.ValueOf(a))
+	expected = map[string]interface{}{"id": uint64(1), "mobileNo": "13800138000", "password": "secret"}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected structMap() returns %v, got %v", expected, m)
+	}
+
+	if _, err = mgr.CreateStruct(nil, a); err == nil {
+		t.Errorf("expected CreateStruct() returns err != nil for non-pointer, got nil")
+	}
+}
+
+func TestCreatesStructKeys(t *testing.T) {
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	var got string
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "test_dbwrapper"}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		got = info.SQL
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, nil
+	}}}
+
+	cases := []struct {
+		objs     []TaggedAccount
+		expected string
+	}{
+		{[]TaggedAccount{{MobileNo: "1"}, {MobileNo: "2"}}, "INSERT INTO `test_dbwrapper` (`mobileNo`,`password`) VALUES (?,?),(?,?)"},
+		{[]TaggedAccount{{ID: 1, MobileNo: "1"}, {ID: 2, MobileNo: "2"}}, "INSERT INTO `test_dbwrapper` (`id`,`mobileNo`,`password`) VALUES (?,?,?),(?,?,?)"},
+	}
+	for _, c := range cases {
+		if _, err := mgr.CreatesStruct(db, &c.objs); err != nil || got != c.expected {
+			t.Errorf("expected mgr.CreatesStruct() renders %s, got %s, err=%v", c.expected, got, err)
+		}
+	}
+
+	mixed := []TaggedAccount{{MobileNo: "1"}, {ID: 2, MobileNo: "2"}}
+	if _, err := mgr.CreatesStruct(db, &mixed); err == nil {
+		t.Errorf("expected mgr.CreatesStruct() rejects records of zero and non-zero primary keys")
+	}
+}
+
+func TestCreateStruct(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	a := TaggedAccount{MobileNo: "13800138000"}
+	_, err := mgr.CreateStruct(db, &a)
+	if err != nil || a.ID == 0 {
+		t.Fatalf("expected mgr.CreateStruct() populates id, got %d, err=%v", a.ID, err)
+	}
+
+	a.Password = "secret"
+	_, err = mgr.UpdateStruct(db, &a)
+	if err != nil {
+		t.Errorf("expected mgr.UpdateStruct() returns err == nil, got %v", err)
+	}
+
+	b := TaggedAccount{}
+	err = mgr.Get(db, &b, mgr.GetColumns(&b), "id", a.ID)
+	if err != nil || b.Password != "secret" || b.Created.IsZero() {
+		t.Errorf("expected mgr.Get() returns password secret and created by database, got %v, err=%v", b, err)
+	}
+
+	_, err = mgr.CreatesStruct(db, &[]TaggedAccount{{MobileNo: "13800138001"}, {MobileNo: "13800138002"}})
+	if err != nil {
+		t.Errorf("expected mgr.CreatesStruct() returns err == nil, got %v", err)
+	}
+
+	tearDown(mgr)
+}
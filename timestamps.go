@@ -0,0 +1,65 @@
+package dbwrapper
+
+import (
+	"time"
+)
+
+// now returns the current time by `Now`, or time.Now if it is nil.
+func (its *DBWrapper) now() time.Time {
+	if its.Now != nil {
+		return its.Now()
+	}
+	return time.Now()
+}
+
+// withTimestamps returns m with `CreatedColumn` and `UpdatedColumn` set to now.
+// If created is true, columns in m are kept as is unless they are zero time, e.g. an unset time.Time field
+// of a struct. Otherwise `CreatedColumn` is skipped and `UpdatedColumn` is always set. m is copied if a column is set.
+func (its *DBWrapper) withTimestamps(m map[string]interface{}, now time.Time, created bool) map[string]interface{} {
+	columns := []string{its.UpdatedColumn}
+	if created {
+		columns = append(columns, its.CreatedColumn)
+	}
+
+	stamped, copied := m, false
+	for _, column := range columns {
+		if column == "" {
+			continue
+		}
+		if v, ok := m[column]; ok && created && !zeroTime(v) {
+			continue
+		}
+		if !copied {
+			stamped, copied = make(map[string]interface{}, len(m)+len(columns)), true
+			for k, v := range m {
+				stamped[k] = v
+			}
+		}
+		stamped[column] = now
+	}
+	return stamped
+}
+
+// withCreatedRows returns rows with `CreatedColumn` and `UpdatedColumn` set to the current time, see withTimestamps.
+func (its *DBWrapper) withCreatedRows(rows []map[string]interface{}) []map[string]interface{} {
+	if its.CreatedColumn == "" && its.UpdatedColumn == "" {
+		return rows
+	}
+	now := its.now()
+	stamped := make([]map[string]interface{}, 0, len(rows))
+	for _, m := range rows {
+		stamped = append(stamped, its.withTimestamps(m, now, true))
+	}
+	return stamped
+}
+
+// zeroTime reports whether v is a zero time.Time or a nil *time.Time, which databases reject or store as NULL.
+func zeroTime(v interface{}) bool {
+	switch t := v.(type) {
+	case time.Time:
+		return t.IsZero()
+	case *time.Time:
+		return t == nil || t.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package dbwrapper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	mgr := &DBWrapper{
+		DriverName:    "mysql",
+		TableName:     "test_dbwrapper",
+		CreatedColumn: "created",
+		UpdatedColumn: "lastModified",
+		Now:           func() time.Time { return now },
+	}
+
+	m := map[string]interface{}{"mobileNo": "13800138000"}
+	stamped := mgr.withTimestamps(m, mgr.now(), true)
+	expected := map[string]interface{}{"mobileNo": "13800138000", "created": now, "lastModified": now}
+	if !reflect.DeepEqual(stamped, expected) {
+		t.Errorf("expected withTimestamps() returns %v, got %v", expected, stamped)
+	}
+	if len(m) != 1 {
+		t.Errorf("expected withTimestamps() keeps m as is, got %v", m)
+	}
+
+	earlier := now.Add(-time.Hour)
+	stamped = mgr.withTimestamps(map[string]interface{}{"created": earlier, "lastModified": earlier}, mgr.now(), false)
+	expected = map[string]interface{}{"created": earlier, "lastModified": now}
+	if !reflect.DeepEqual(stamped, expected) {
+		t.Errorf("expected withTimestamps() returns %v, got %v", expected, stamped)
+	}
+
+	rows := mgr.withCreatedRows([]map[string]interface{}{{"mobileNo": "1"}, {"mobileNo": "2", "created": earlier}})
+	if rows[0]["created"] != now || rows[1]["created"] != earlier || rows[1]["lastModified"] != now {
+		t.Errorf("expected withCreatedRows() sets missing timestamps, got %v", rows)
+	}
+
+	st, _ := mgr.newStmt()
+	s, err := mgr.upsertStmt(st, stamped, []string{"id"})
+	expectedSQL := "INSERT INTO `test_dbwrapper` (`created`,`lastModified`) VALUES (?,?) ON DUPLICATE KEY UPDATE `lastModified`=VALUES(`lastModified`)"
+	if err != nil || s != expectedSQL {
+		t.Errorf("expected upsertStmt() keeps created, got %s, err=%v", s, err)
+	}
+
+	mgr.SoftDeleteColumn = "deleted_at"
+	st, _ = mgr.newStmt()
+	if _, err := mgr.deleteStmt(st, Eq("id", 1), 0, false); err != nil || st.args[0] != now {
+		t.Errorf("expected deleteStmt() binds Now, got %v, err=%v", st.args, err)
+	}
+
+	if unset := (&DBWrapper{}).withTimestamps(m, now, true); !reflect.DeepEqual(unset, m) {
+		t.Errorf("expected withTimestamps() returns m if columns are not set, got %v", unset)
+	}
+}
+
+func TestTimestampsOfStruct(t *testing.T) {
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var got map[string]interface{}
+	mgr := &DBWrapper{
+		DriverName:    "mysql",
+		TableName:     "test_dbwrapper",
+		CreatedColumn: "created",
+		UpdatedColumn: "lastModified",
+		Now:           func() time.Time { return now },
+	}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		got = map[string]interface{}{}
+		for i, column := range info.columns {
+			got[column] = info.Args[i]
+		}
+		info.Skip, info.Result = true, insertResult(1)
+		return ctx, nil
+	}}}
+
+	type record struct {
+		ID           int64     `db:"id,pk"`
+		MobileNo     string    `db:"mobileNo"`
+		Created      time.Time `db:"created"`
+		LastModified time.Time `db:"lastModified"`
+	}
+	r := record{MobileNo: "13800138000"}
+	if _, err := mgr.CreateStruct(db, &r); err != nil {
+		t.Fatalf("expected mgr.CreateStruct() returns err == nil, got %v", err)
+	}
+	if got["created"] != now || got["lastModified"] != now {
+		t.Errorf("expected mgr.CreateStruct() sets zero timestamps to Now, got %v", got)
+	}
+
+	r.Created = now.Add(-time.Hour)
+	r.LastModified = now.Add(-time.Hour)
+	if _, err := mgr.UpdateStruct(db, &r); err != nil {
+		t.Fatalf("expected mgr.UpdateStruct() returns err == nil, got %v", err)
+	}
+	if got["created"] != r.Created || got["lastModified"] != now {
+		t.Errorf("expected mgr.UpdateStruct() keeps created and sets lastModified to Now, got %v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package dbwrapper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tracer opens spans around statements of DBWrapper methods, see DBWrapper.Tracer.
+// It is small enough to be adapted to OpenTelemetry, e.g.
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, dbwrapper.Span) {
+//		ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start opens a span named name, the returned context carries it to the statement and hooks.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation traced by Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Attributes of spans.
+const (
+	AttrDBSystem     = "db.system"
+	AttrDBOperation  = "db.operation"
+	AttrDBTable      = "db.sql.table"
+	AttrDBStatement  = "db.statement"
+	AttrRowsAffected = "db.rows_affected"
+	AttrErrorClass   = "db.error.class"
+)
+
+// NoopTracer opens spans which do nothing, it is the default of DBWrapper.Tracer.
+var NoopTracer Tracer = noopTracer{}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) RecordError(err error) {}
+
+func (noopSpan) End() {}
+
+// tracer returns `Tracer`, NoopTracer if it is nil.
+func (its *DBWrapper) tracer() Tracer {
+	if its.Tracer == nil {
+		return NoopTracer
+	}
+	return its.Tracer
+}
+
+// RecordedSpan is a span recorded by SpanRecorder.
+type RecordedSpan struct {
+	Name       string
+	Parent     *RecordedSpan
+	Attributes map[string]interface{}
+	Errors     []error
+	Start      time.Time
+	End        time.Time
+	Ended      bool
+}
+
+// SpanRecorder is a Tracer which records spans in memory, for tests.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// recordedSpanKey is the context key of the current span of SpanRecorder.
+type recordedSpanKey struct{}
+
+func (r *SpanRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(recordedSpanKey{}).(*RecordedSpan)
+	s := &RecordedSpan{Name: name, Parent: parent, Attributes: map[string]interface{}{}, Start: time.Now()}
+
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+	return context.WithValue(ctx, recordedSpanKey{}, s), recorderSpan{r, s}
+}
+
+// Spans returns copies of spans recorded so far in order of start.
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(r.spans))
+	for _, s := range r.spans {
+		c := *s
+		c.Attributes = make(map[string]interface{}, len(s.Attributes))
+		for k, v := range s.Attributes {
+			c.Attributes[k] = v
+		}
+		c.Errors = append([]error{}, s.Errors...)
+		spans = append(spans, c)
+	}
+	return spans
+}
+
+// Reset drops recorded spans.
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+type recorderSpan struct {
+	r *SpanRecorder
+	s *RecordedSpan
+}
+
+func (rs recorderSpan) SetAttribute(key string, value interface{}) {
+	rs.r.mu.Lock()
+	defer rs.r.mu.Unlock()
+	rs.s.Attributes[key] = value
+}
+
+func (rs recorderSpan) RecordError(err error) {
+	rs.r.mu.Lock()
+	defer rs.r.mu.Unlock()
+	rs.s.Errors = append(rs.s.Errors, err)
+}
+
+func (rs recorderSpan) End() {
+	rs.r.mu.Lock()
+	defer rs.r.mu.Unlock()
+	rs.s.End = time.Now()
+	rs.s.Ended = true
+}
@@ -0,0 +1,48 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestTracer(t *testing.T) {
+	r := &SpanRecorder{}
+	mgr := &DBWrapper{TableName: "test_dbwrapper", Tracer: r}
+
+	ctx, parent := r.Start(context.Background(), "request")
+	var inner context.Context
+	mgr.run(ctx, PostgreSQL, &QueryInfo{Op: "Update", SQL: `UPDATE "test_dbwrapper" SET "name"=$1 WHERE "id" = $2`}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		inner = ctx
+		return insertResult(1), nil
+	})
+	mgr.run(ctx, PostgreSQL, &QueryInfo{Op: "Create"}, func(ctx context.Context, s string, args []interface{}) (sql.Result, error) {
+		return nil, &pq.Error{Code: "23505"}
+	})
+	parent.End()
+
+	spans := r.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %v", spans)
+	}
+	update, create := spans[1], spans[2]
+	if update.Name != "Update test_dbwrapper" || update.Parent == nil || update.Parent.Name != "request" || !update.Ended {
+		t.Errorf("expected a child span of the method and table, got %+v", update)
+	}
+	if inner.Value(recordedSpanKey{}) == nil {
+		t.Errorf("expected the span is propagated to the statement by context")
+	}
+	if update.Attributes[AttrDBStatement] != `UPDATE "test_dbwrapper" SET "name"=? WHERE "id" = ?` || update.Attributes[AttrRowsAffected] != int64(1) || update.Attributes[AttrDBSystem] != "postgres" {
+		t.Errorf("expected attributes of the statement, got %v", update.Attributes)
+	}
+	if create.Attributes[AttrErrorClass] != "duplicated_unique_key" || len(create.Errors) != 1 {
+		t.Errorf("expected the error class of the failed statement, got %+v", create)
+	}
+
+	mgr.Tracer = nil
+	if _, span := mgr.tracer().Start(context.Background(), "noop"); span != (noopSpan{}) {
+		t.Errorf("expected NoopTracer by default, got %v", span)
+	}
+}
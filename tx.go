@@ -0,0 +1,113 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Executor runs statements, both *sqlx.DB and *sqlx.Tx satisfy it.
+type Executor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// savepointSeq makes savepoint names unique.
+var savepointSeq uint64
+
+// executor returns db, or the shared pool if db is nil.
+func (its *DBWrapper) executor(ctx context.Context, db Executor) (e Executor, err error) {
+	if !isNilExecutor(db) {
+		return db, nil
+	}
+
+	conn, err := its.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// isNilExecutor reports whether db is nil, including a typed nil *sqlx.DB or *sqlx.Tx.
+func isNilExecutor(db Executor) bool {
+	switch v := db.(type) {
+	case nil:
+		return true
+	case *sqlx.DB:
+		return v == nil
+	case *sqlx.Tx:
+		return v == nil
+	}
+	return false
+}
+
+// WithTx runs fn in a transaction, commits if fn returns nil, otherwise rolls back.
+// A panic in fn rolls back and then re-panics.
+// If db is a *sqlx.Tx, fn runs in a savepoint of it, so WithTx can be nested.
+func (its *DBWrapper) WithTx(ctx context.Context, db Executor, fn func(tx *sqlx.Tx) error) (err error) {
+	if tx, ok := db.(*sqlx.Tx); ok && tx != nil {
+		return its.withSavepoint(ctx, tx, fn)
+	}
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	conn, ok := db.(*sqlx.DB)
+	if !ok {
+		return fmt.Errorf("can not begin transaction on %T", db)
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			err = errors.Join(err, errRollback)
+		}
+		return
+	}
+	return tx.Commit()
+}
+
+// withSavepoint runs fn inside a savepoint of tx.
+func (its *DBWrapper) withSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(tx *sqlx.Tx) error) (err error) {
+	name := fmt.Sprintf("dbwrapper_sp_%d", atomic.AddUint64(&savepointSeq, 1))
+	its.logDebug(ctx, "sql", "op", "WithTx", "sql", "SAVEPOINT "+name)
+	_, err = tx.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		if _, errRollback := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); errRollback != nil {
+			err = errors.Join(err, errRollback)
+		}
+		return
+	}
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return
+}
@@ -0,0 +1,87 @@
+package dbwrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestIsNilExecutor(t *testing.T) {
+	var db *sqlx.DB
+	var tx *sqlx.Tx
+	if !isNilExecutor(nil) || !isNilExecutor(db) || !isNilExecutor(tx) {
+		t.Errorf("expected isNilExecutor() returns true for nil and typed nil, got false")
+	}
+	if isNilExecutor(&sqlx.DB{}) {
+		t.Errorf("expected isNilExecutor() returns false for *sqlx.DB, got true")
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	// Test Rollback
+	err := mgr.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		_, err := mgr.CreateContext(ctx, tx, &map[string]interface{}{
+			"mobileNo": "13800138000",
+		})
+		if err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Errorf("expected mgr.WithTx() returns errAbort, got %v", err)
+	}
+
+	accounts := []Account{}
+	err = mgr.Gets(db, &accounts, []string{"id", "mobileNo"}, &map[string]interface{}{}, 10)
+	if err != nil || len(accounts) != 0 {
+		t.Errorf("expected mgr.WithTx() rolls back, got %d records, err=%v", len(accounts), err)
+	}
+
+	// Test Commit with a rolled back savepoint
+	err = mgr.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		_, err := mgr.CreateContext(ctx, tx, &map[string]interface{}{
+			"mobileNo": "13800138000",
+		})
+		if err != nil {
+			return err
+		}
+
+		err = mgr.WithTx(ctx, tx, func(tx *sqlx.Tx) error {
+			_, err := mgr.CreateContext(ctx, tx, &map[string]interface{}{
+				"mobileNo": "13800138001",
+			})
+			if err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if err != errAbort {
+			t.Errorf("expected nested mgr.WithTx() returns errAbort, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected mgr.WithTx() returns err == nil, got %v", err)
+	}
+
+	accounts = []Account{}
+	err = mgr.Gets(db, &accounts, []string{"id", "mobileNo"}, &map[string]interface{}{}, 10)
+	if err != nil || len(accounts) != 1 || accounts[0].MobileNo != "13800138000" {
+		t.Errorf("expected mgr.WithTx() commits 1 record, got %v, err=%v", accounts, err)
+	}
+
+	tearDown(mgr)
+}
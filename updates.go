@@ -0,0 +1,275 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// valuesUpdater is implemented by dialects which update rows joined with a VALUES list,
+// `UPDATE ... FROM (VALUES ...)`, instead of `CASE`. Values are cast to types of the columns,
+// which are queried from the catalog.
+type valuesUpdater interface {
+	// columnTypesSQL returns the query of `name` and `type` of columns of the table bound to placeholder table.
+	columnTypesSQL(table string) string
+}
+
+func (postgresDialect) columnTypesSQL(table string) string {
+	return "SELECT a.attname AS name, format_type(a.atttypid, a.atttypmod) AS type FROM pg_attribute a " +
+		"WHERE a.attrelid = " + table + "::regclass AND a.attnum > 0 AND NOT a.attisdropped"
+}
+
+// columnType is a row of valuesUpdater.columnTypesSQL.
+type columnType struct {
+	Name string `db:"name"`
+	Type string `db:"type"`
+}
+
+// columnTypes returns types of columns of the table by name, they are queried once and cached.
+func (its *DBWrapper) columnTypes(ctx context.Context, db Executor, u valuesUpdater) (types map[string]string, err error) {
+	its.typesMu.Lock()
+	types = its.types[its.TableName]
+	its.typesMu.Unlock()
+	if types != nil {
+		return
+	}
+
+	st, err := its.newStmt()
+	if err != nil {
+		return
+	}
+	rows := []columnType{}
+	s := u.columnTypesSQL(st.bind("", st.table))
+	if err = its.selectRows(ctx, db, "ColumnTypes", st, &rows, s); err != nil {
+		return
+	}
+
+	types = make(map[string]string, len(rows))
+	for _, row := range rows {
+		types[row.Name] = row.Type
+	}
+	its.typesMu.Lock()
+	if its.types == nil {
+		its.types = map[string]map[string]string{}
+	}
+	its.types[its.TableName] = types
+	its.typesMu.Unlock()
+	return
+}
+
+// Updates update records of different values in bulk, each of changes is keyed by pkName.
+// MySQL renders `UPDATE ... SET col = CASE pk WHEN ... END`, PostgreSQL `UPDATE ... FROM (VALUES ...)`.
+// changes must have the same keys, they are split into batches like Creates, the result is a *BatchResult.
+// `UpdatedColumn` is set to the current time in each of changes.
+func (its *DBWrapper) Updates(db Executor, pkName string, changes []map[string]interface{}) (result sql.Result, err error) {
+	return its.UpdatesContext(context.Background(), db, pkName, changes)
+}
+
+// UpdatesContext is like Updates but runs with ctx.
+func (its *DBWrapper) UpdatesContext(ctx context.Context, db Executor, pkName string, changes []map[string]interface{}) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	if len(changes) == 0 {
+		err = errors.New("Updates requires changes")
+		return
+	}
+	if its.UpdatedColumn != "" && its.UpdatedColumn != pkName {
+		now, stamped := its.now(), make([]map[string]interface{}, 0, len(changes))
+		for _, m := range changes {
+			stamped = append(stamped, its.withTimestamps(m, now, false))
+		}
+		changes = stamped
+	}
+	keys := []string{}
+	for _, k := range sortedKeys(changes[0]) {
+		if k != pkName {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		err = errors.New("Updates requires columns to update")
+		return
+	}
+	for _, m := range changes {
+		if _, ok := m[pkName]; !ok || len(m) != len(keys)+1 {
+			err = fmt.Errorf("changes of Updates must have the same keys including %s", pkName)
+			return
+		}
+	}
+
+	d, err := its.Dialect()
+	if err != nil {
+		return
+	}
+	// placeholders of a row
+	perRow := 2*len(keys) + 1
+	var types map[string]string
+	if u, ok := d.(valuesUpdater); ok {
+		perRow = len(keys) + 1
+		if types, err = its.columnTypes(ctx, db, u); err != nil {
+			return
+		}
+	}
+
+	batch := &BatchResult{}
+	err = its.inBatches(ctx, db, len(changes), its.batchSize(perRow), func(ctx context.Context, db Executor, lo, hi int) error {
+		st, err := its.newStmt()
+		if err != nil {
+			return err
+		}
+		s, err := its.updatesStmt(st, pkName, keys, changes[lo:hi], types)
+		if err != nil {
+			return err
+		}
+		r, err := its.exec(ctx, db, "Updates", st, s)
+		if err != nil {
+			return err
+		}
+		batch.add(r)
+		return nil
+	})
+	return batch, err
+}
+
+// UpdatesStruct update records in bulk from tag `db` of structs, keyed by the primary key.
+// Readonly columns are not written, `omitempty` is not applied because records must have the same columns.
+// parameter `objs` must be pass by `&[]MyObject{}` or `&[]*MyObject{}`.
+func (its *DBWrapper) UpdatesStruct(db Executor, objs interface{}) (result sql.Result, err error) {
+	return its.UpdatesStructContext(context.Background(), db, objs)
+}
+
+// UpdatesStructContext is like UpdatesStruct but runs with ctx.
+func (its *DBWrapper) UpdatesStructContext(ctx context.Context, db Executor, objs interface{}) (result sql.Result, err error) {
+	rv := reflect.ValueOf(objs)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice || rv.Len() == 0 {
+		err = fmt.Errorf("UpdatesStruct requires a non-empty slice of struct, got %T", objs)
+		return
+	}
+
+	changes := make([]map[string]interface{}, 0, rv.Len())
+	var pk field
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i)
+		for v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			err = fmt.Errorf("UpdatesStruct requires a slice of struct, got %T", objs)
+			return
+		}
+
+		fields := structFields(v.Type())
+		var ok bool
+		pk, ok = pkField(fields, its.pkName())
+		if !ok {
+			err = fmt.Errorf("%v has no primary key field %s", v.Type(), its.pkName())
+			return
+		}
+		if v.Field(pk.index).IsZero() {
+			err = errors.New("UpdatesStruct requires non-zero primary keys")
+			return
+		}
+		m := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if !f.readonly {
+				m[f.name] = v.Field(f.index).Interface()
+			}
+		}
+		changes = append(changes, m)
+	}
+	return its.UpdatesContext(ctx, db, pk.name, changes)
+}
+
+// updatesStmt renders UPDATE of rows, values of keys in each row keyed by pkName.
+// types are types of columns, required by valuesUpdater dialects.
+func (its *DBWrapper) updatesStmt(st *stmt, pkName string, keys []string, rows []map[string]interface{}, types map[string]string) (string, error) {
+	pk, err := st.column(pkName)
+	if err != nil {
+		return "", err
+	}
+	columns, err := st.columns(keys)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := st.d.(valuesUpdater); ok {
+		return its.updatesFromValues(st, pkName, pk, keys, columns, rows, types)
+	}
+
+	sets := make([]string, 0, len(keys))
+	for i, k := range keys {
+		whens := make([]string, 0, len(rows))
+		for _, row := range rows {
+			whens = append(whens, fmt.Sprintf("WHEN %s THEN %s", st.bind(pkName, row[pkName]), st.bind(k, updateValue(row[k]))))
+		}
+		sets = append(sets, fmt.Sprintf("%s = CASE %s %s END", columns[i], pk, strings.Join(whens, " ")))
+	}
+
+	pks := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		pks = append(pks, row[pkName])
+	}
+	w, err := whereClause(st, In(pkName, pks...))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", st.table, strings.Join(sets, ","), w), nil
+}
+
+// updatesFromValues renders `UPDATE ... FROM (VALUES ...)` of rows, placeholders of the first row are cast
+// to types of the columns, e.g. `$1::uuid`, so VALUES has the types of the table.
+func (its *DBWrapper) updatesFromValues(st *stmt, pkName, pk string, keys, columns []string, rows []map[string]interface{}, types map[string]string) (string, error) {
+	names := append([]string{pkName}, keys...)
+	casts := make([]string, len(names))
+	for i, name := range names {
+		if casts[i] = types[name]; casts[i] == "" {
+			return "", fmt.Errorf("Updates got column %s which is not found in table %s", name, st.tableName)
+		}
+	}
+
+	values := make([]string, 0, len(rows))
+	for j, row := range rows {
+		placeholders := make([]string, 0, len(names))
+		for i, name := range names {
+			p := st.bind(name, updateValue(row[name]))
+			if j == 0 {
+				p += "::" + casts[i]
+			}
+			placeholders = append(placeholders, p)
+		}
+		values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+	}
+
+	sets := make([]string, 0, len(keys))
+	for _, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = v.%s", column, column))
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s FROM (VALUES %s) AS v(%s) WHERE %s.%s = v.%s",
+		st.table,
+		strings.Join(sets, ","),
+		strings.Join(values, ","),
+		strings.Join(append([]string{pk}, columns...), ","),
+		st.table, pk, pk,
+	), nil
+}
+
+// updateValue converts maps into JSONB like Creates.
+func updateValue(v interface{}) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return JSONB(m)
+	}
+	return v
+}
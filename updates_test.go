@@ -0,0 +1,157 @@
+package dbwrapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestUpdatesStmt(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"id": 1, "price": 9.5, "name": "a", "note": nil},
+		{"id": 2, "price": 12.0, "name": "b", "note": nil},
+	}
+	keys := []string{"name", "note", "price"}
+
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "items"}
+	st, _ := mgr.newStmt()
+	s, err := mgr.updatesStmt(st, "id", keys, rows, nil)
+	expected := "UPDATE `items` SET " +
+		"`name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+		"`note` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+		"`price` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END " +
+		"WHERE `id` IN (?,?)"
+	if err != nil || s != expected {
+		t.Errorf("expected updatesStmt() returns %s, got %s, err=%v", expected, s, err)
+	}
+	if len(st.args) != 14 || st.args[0] != 1 || st.args[1] != "a" {
+		t.Errorf("expected args of CASE, got %v", st.args)
+	}
+
+	mgr.DriverName = "postgres"
+	types := map[string]string{"id": "bigint", "name": "character varying(32)", "note": "text", "price": "numeric(10,2)"}
+	st, _ = mgr.newStmt()
+	s, err = mgr.updatesStmt(st, "id", keys, rows, types)
+	expected = `UPDATE "items" SET "name" = v."name","note" = v."note","price" = v."price" ` +
+		`FROM (VALUES ($1::bigint,$2::character varying(32),$3::text,$4::numeric(10,2)),($5,$6,$7,$8)) AS v("id","name","note","price") ` +
+		`WHERE "items"."id" = v."id"`
+	if err != nil || s != expected {
+		t.Errorf("expected updatesStmt() returns %s, got %s, err=%v", expected, s, err)
+	}
+
+	// a string key is cast to the type of the column, not text
+	uuids := []map[string]interface{}{
+		{"id": "0b6f6d4e-5b1c-4a57-9a4e-6d3f1c2b7a10", "status": "paid"},
+		{"id": "6a1c2f3e-8d4b-4f0a-b2c1-3e5d7f9a1b2c", "status": "shipped"},
+	}
+	st, _ = mgr.newStmt()
+	s, err = mgr.updatesStmt(st, "id", []string{"status"}, uuids, map[string]string{"id": "uuid", "status": "order_status"})
+	expected = `UPDATE "items" SET "status" = v."status" ` +
+		`FROM (VALUES ($1::uuid,$2::order_status),($3,$4)) AS v("id","status") ` +
+		`WHERE "items"."id" = v."id"`
+	if err != nil || s != expected {
+		t.Errorf("expected updatesStmt() returns %s, got %s, err=%v", expected, s, err)
+	}
+
+	st, _ = mgr.newStmt()
+	if _, err = mgr.updatesStmt(st, "id", []string{"missing"}, []map[string]interface{}{{"id": 1, "missing": 1}}, types); err == nil {
+		t.Errorf("expected updatesStmt() rejects columns without type")
+	}
+}
+
+func TestColumnTypes(t *testing.T) {
+	db, _ := sqlx.Open("postgres", "postgres://127.0.0.1:1/test")
+	defer db.Close()
+
+	queries := []string{}
+	mgr := &DBWrapper{DriverName: "postgres", TableName: "items"}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		queries = append(queries, info.Op)
+		info.Skip = true
+		if rows, ok := info.Dest.(*[]columnType); ok {
+			*rows = []columnType{{"id", "uuid"}, {"status", "text"}}
+		} else {
+			info.Result = insertResult(1)
+		}
+		return ctx, nil
+	}}}
+
+	changes := []map[string]interface{}{{"id": "0b6f6d4e-5b1c-4a57-9a4e-6d3f1c2b7a10", "status": "paid"}}
+	for i := 0; i < 2; i++ {
+		if _, err := mgr.Updates(db, "id", changes); err != nil {
+			t.Fatalf("expected mgr.Updates() returns err == nil, got %v", err)
+		}
+	}
+	if fmt.Sprint(queries) != "[ColumnTypes Updates Updates]" {
+		t.Errorf("expected types of columns are queried once, got %v", queries)
+	}
+}
+
+func TestUpdatesTimestamps(t *testing.T) {
+	db, _ := sqlx.Open("mysql", "root@tcp(127.0.0.1:1)/test")
+	defer db.Close()
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var got *QueryInfo
+	mgr := &DBWrapper{DriverName: "mysql", TableName: "items", UpdatedColumn: "lastModified", Now: func() time.Time { return now }}
+	mgr.Hooks = []Hook{funcHook{before: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+		got = info
+		info.Skip, info.Result = true, insertResult(2)
+		return ctx, nil
+	}}}
+
+	changes := []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}}
+	if _, err := mgr.Updates(db, "id", changes); err != nil {
+		t.Fatalf("expected mgr.Updates() returns err == nil, got %v", err)
+	}
+	expected := "UPDATE `items` SET " +
+		"`lastModified` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+		"`name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END " +
+		"WHERE `id` IN (?,?)"
+	if got.SQL != expected || got.Args[1] != now || got.Args[3] != now {
+		t.Errorf("expected mgr.Updates() sets lastModified to Now, got %s %v", got.SQL, got.Args)
+	}
+	if len(changes[0]) != 2 {
+		t.Errorf("expected mgr.Updates() keeps changes as is, got %v", changes[0])
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	items := []map[string]interface{}{
+		{"mobileNo": "13800138000", "password": "a"},
+		{"mobileNo": "13800138001", "password": "b"},
+	}
+	_, err := mgr.Creates(db, &items)
+	if err != nil {
+		t.Fatalf("expected mgr.Creates() returns err == nil, got %v", err)
+	}
+	accounts := []Account{}
+	mgr.GetsWhere(db, &accounts, nil, nil, 0, ListOptions{OrderBy: []Order{Asc("id")}})
+
+	changes := []map[string]interface{}{
+		{"id": accounts[0].ID, "password": "x"},
+		{"id": accounts[1].ID, "password": "y"},
+	}
+	result, err := mgr.Updates(db, "id", changes)
+	if n, _ := result.RowsAffected(); err != nil || n != 2 {
+		t.Errorf("expected mgr.Updates() updates 2 records, got %d, err=%v", n, err)
+	}
+
+	account := Account{}
+	mgr.Get(db, &account, nil, "id", accounts[1].ID)
+	if account.Password != "y" {
+		t.Errorf("expected password y, got %s", account.Password)
+	}
+
+	tearDown(mgr)
+}
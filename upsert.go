@@ -0,0 +1,123 @@
+package dbwrapper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// UpsertOptions tunes CreatesOrUpdate.
+type UpsertOptions struct {
+	// ConflictKeys is the unique key the conflict is detected on, `ConflictKeys` of the wrapper if empty.
+	ConflictKeys []string
+
+	// Update are columns updated on conflict, all inserted columns except the conflict keys if empty.
+	Update []string
+
+	// Keep are columns left untouched on conflict, e.g. "created".
+	Keep []string
+}
+
+// CreatesOrUpdate insert records in bulk, records conflicted with existing ones update them instead,
+// by `ON DUPLICATE KEY UPDATE` of MySQL or `ON CONFLICT (...) DO UPDATE` of PostgreSQL.
+// Records are split into batches like Creates, the result is a *BatchResult.
+// Note MySQL counts 2 rows affected for an updated record and 0 for an unchanged one.
+func (its *DBWrapper) CreatesOrUpdate(db Executor, items *[]map[string]interface{}, opts ...UpsertOptions) (result sql.Result, err error) {
+	return its.CreatesOrUpdateContext(context.Background(), db, items, opts...)
+}
+
+// CreatesOrUpdateContext is like CreatesOrUpdate but runs with ctx.
+func (its *DBWrapper) CreatesOrUpdateContext(ctx context.Context, db Executor, items *[]map[string]interface{}, opts ...UpsertOptions) (result sql.Result, err error) {
+	ctx, cancel := its.withTimeout(ctx)
+	defer cancel()
+
+	db, err = its.executor(ctx, db)
+	if err != nil {
+		return
+	}
+
+	if len(*items) == 0 {
+		err = errors.New("CreatesOrUpdate requires records")
+		return
+	}
+	rows := its.withCreatedRows(*items)
+	createKeys := sortedKeys(rows[0])
+	for _, itemMap := range rows {
+		if len(itemMap) != len(createKeys) {
+			err = errors.New("count of keys must be equal in bulk insert")
+			return
+		}
+	}
+	o := its.upsertOptions(opts)
+
+	batch := &BatchResult{}
+	err = its.inBatches(ctx, db, len(rows), its.batchSize(len(createKeys)), func(ctx context.Context, db Executor, lo, hi int) error {
+		st, err := its.newStmt()
+		if err != nil {
+			return err
+		}
+		s, err := its.upsertRowsStmt(st, createKeys, rows[lo:hi], o)
+		if err != nil {
+			return err
+		}
+		r, err := its.exec(ctx, db, "CreatesOrUpdate", st, s)
+		if err != nil {
+			return err
+		}
+		batch.add(r)
+		return nil
+	})
+	return batch, err
+}
+
+// upsertOptions merges opts into UpsertOptions of the wrapper, later options win.
+// `CreatedColumn` is kept on conflict.
+func (its *DBWrapper) upsertOptions(opts []UpsertOptions) UpsertOptions {
+	o := UpsertOptions{ConflictKeys: its.ConflictKeys}
+	if its.CreatedColumn != "" {
+		o.Keep = append(o.Keep, its.CreatedColumn)
+	}
+	for _, opt := range opts {
+		if len(opt.ConflictKeys) > 0 {
+			o.ConflictKeys = opt.ConflictKeys
+		}
+		if len(opt.Update) > 0 {
+			o.Update = opt.Update
+		}
+		o.Keep = append(o.Keep, opt.Keep...)
+	}
+	return o
+}
+
+// upsertRowsStmt renders INSERT of rows which updates columns of o on conflict.
+func (its *DBWrapper) upsertRowsStmt(st *stmt, keys []string, rows []map[string]interface{}, o UpsertOptions) (string, error) {
+	s, err := its.insertRowsStmt(st, keys, rows)
+	if err != nil {
+		return "", err
+	}
+
+	updates := o.Update
+	if len(updates) == 0 {
+		updates = keys
+	}
+	columns := []string{}
+	for _, k := range updates {
+		if !inStrings(o.ConflictKeys, k) && !inStrings(o.Keep, k) {
+			columns = append(columns, k)
+		}
+	}
+
+	quotedUpdates, err := st.columns(columns)
+	if err != nil {
+		return "", err
+	}
+	conflictKeys, err := st.columns(o.ConflictKeys)
+	if err != nil {
+		return "", err
+	}
+	upsert, err := st.d.Upsert(conflictKeys, quotedUpdates)
+	if err != nil {
+		return "", err
+	}
+	return s + " " + upsert, nil
+}
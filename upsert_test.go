@@ -0,0 +1,95 @@
+package dbwrapper
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUpsertRowsStmt(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"mobileNo": "13800138000", "password": "a", "created": "2020-01-01"},
+		{"mobileNo": "13800138001", "password": "b", "created": "2020-01-01"},
+	}
+	keys := sortedKeys(rows[0])
+	cases := []struct {
+		driverName string
+		opts       []UpsertOptions
+		expected   string
+	}{
+		{
+			"mysql",
+			nil,
+			"INSERT INTO `test_dbwrapper` (`created`,`mobileNo`,`password`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `created`=VALUES(`created`),`mobileNo`=VALUES(`mobileNo`),`password`=VALUES(`password`)",
+		},
+		{
+			"mysql",
+			[]UpsertOptions{{ConflictKeys: []string{"mobileNo"}, Keep: []string{"created"}}},
+			"INSERT INTO `test_dbwrapper` (`created`,`mobileNo`,`password`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `password`=VALUES(`password`)",
+		},
+		{
+			"postgres",
+			[]UpsertOptions{{ConflictKeys: []string{"mobileNo"}, Keep: []string{"created"}}},
+			`INSERT INTO "test_dbwrapper" ("created","mobileNo","password") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("mobileNo") DO UPDATE SET "password"=EXCLUDED."password"`,
+		},
+		{
+			"postgres",
+			[]UpsertOptions{{ConflictKeys: []string{"mobileNo"}}, {Update: []string{"created"}}},
+			`INSERT INTO "test_dbwrapper" ("created","mobileNo","password") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("mobileNo") DO UPDATE SET "created"=EXCLUDED."created"`,
+		},
+		{
+			"postgres",
+			[]UpsertOptions{{ConflictKeys: []string{"mobileNo"}, Update: []string{"mobileNo"}}},
+			`INSERT INTO "test_dbwrapper" ("created","mobileNo","password") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("mobileNo") DO NOTHING`,
+		},
+	}
+
+	for _, c := range cases {
+		mgr := &DBWrapper{DriverName: c.driverName, TableName: "test_dbwrapper"}
+		st, _ := mgr.newStmt()
+		s, err := mgr.upsertRowsStmt(st, keys, rows, mgr.upsertOptions(c.opts))
+		if err != nil || s != c.expected {
+			t.Errorf("expected upsertRowsStmt() returns %s, got %s, err=%v", c.expected, s, err)
+		}
+	}
+
+	mgr := &DBWrapper{DriverName: "postgres", TableName: "test_dbwrapper"}
+	st, _ := mgr.newStmt()
+	if _, err := mgr.upsertRowsStmt(st, keys, rows, mgr.upsertOptions(nil)); err == nil {
+		t.Errorf("expected upsertRowsStmt() requires conflict keys of postgres")
+	}
+}
+
+func TestCreatesOrUpdate(t *testing.T) {
+	mgr := NewAccountProxy()
+	db := mgr.MustOpenDB()
+	defer db.Close()
+
+	tearDown(mgr)
+	setUp(mgr)
+
+	items := []map[string]interface{}{}
+	for i := 0; i < 3; i++ {
+		items = append(items, map[string]interface{}{"mobileNo": fmt.Sprintf("1380013800%d", i), "password": "old"})
+	}
+	_, err := mgr.Creates(db, &items)
+	if err != nil {
+		t.Fatalf("expected mgr.Creates() returns err == nil, got %v", err)
+	}
+
+	for _, item := range items {
+		item["password"] = "new"
+	}
+	items = append(items, map[string]interface{}{"mobileNo": "13800138003", "password": "new"})
+	_, err = mgr.CreatesOrUpdate(db, &items, UpsertOptions{ConflictKeys: []string{"mobileNo"}})
+	if err != nil {
+		t.Fatalf("expected mgr.CreatesOrUpdate() returns err == nil, got %v", err)
+	}
+
+	accounts := []Account{}
+	err = mgr.GetsWhere(db, &accounts, nil, Eq("password", "new"), 0)
+	if err != nil || len(accounts) != 4 {
+		t.Errorf("expected mgr.CreatesOrUpdate() writes 4 records, got %d, err=%v", len(accounts), err)
+	}
+
+	tearDown(mgr)
+}